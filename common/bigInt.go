@@ -0,0 +1,16 @@
+package common
+
+import (
+	"encoding/hex"
+	"math/big"
+)
+
+// HexBigInt encodes the value as an even length hex string, as smart contract call arguments are, 0 being encoded
+// as "00"
+func HexBigInt(value *big.Int) string {
+	if value.Sign() == 0 {
+		return "00"
+	}
+
+	return hex.EncodeToString(value.Bytes())
+}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// egldDecimals is the number of decimals of the EGLD denomination
+const egldDecimals = 18
+
+// ParseEGLDValue converts a decimal EGLD value, as 1250.5, to its smallest denomination. Only digits and at most 18
+// decimals are accepted, so negative values are rejected.
+func ParseEGLDValue(value string) (*big.Int, error) {
+	integer, fraction, _ := strings.Cut(value, ".")
+	if len(fraction) > egldDecimals {
+		return nil, fmt.Errorf("too many decimals in %s", value)
+	}
+	if len(integer) == 0 || !isDigits(integer) || !isDigits(fraction) {
+		return nil, fmt.Errorf("invalid EGLD value %s", value)
+	}
+
+	denominated, _ := big.NewInt(0).SetString(integer+fraction+strings.Repeat("0", egldDecimals-len(fraction)), 10)
+
+	return denominated, nil
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestParseEGLDValue(t *testing.T) {
+	accepted := map[string]string{
+		"0":                    "0",
+		"1":                    "1000000000000000000",
+		"2500":                 "2500000000000000000000",
+		"1250.5":               "1250500000000000000000",
+		"2.":                   "2000000000000000000",
+		"0.000000000000000001": "1",
+	}
+	for value, expected := range accepted {
+		denominated, err := ParseEGLDValue(value)
+		if err != nil {
+			t.Errorf("%q should be accepted, got %v", value, err)
+			continue
+		}
+		if denominated.String() != expected {
+			t.Errorf("%q was parsed as %s, expected %s", value, denominated, expected)
+		}
+	}
+
+	rejected := []string{"", "abc", ".5", "1.-5", "-", "-5", "+5", "1,5", "0.0000000000000000001"}
+	for _, value := range rejected {
+		denominated, err := ParseEGLDValue(value)
+		if err == nil {
+			t.Errorf("%q should be rejected, got %s", value, denominated)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// SaveJSONFile writes the value as indented JSON. The JSON is written in a temporary file first, then renamed over the
+// destination, so a crash never leaves a truncated file behind.
+func SaveJSONFile(filename string, value interface{}) error {
+	buff, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFilename := filename + ".tmp"
+	err = os.WriteFile(tmpFilename, buff, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, filename)
+}
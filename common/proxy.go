@@ -0,0 +1,24 @@
+package common
+
+import (
+	"time"
+
+	"github.com/multiversx/mx-sdk-go/blockchain"
+	"github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+// NewProxy creates the proxy used by all the tools, for a local testnet use "http://127.0.0.1:7950" as gateway
+func NewProxy(gateway string) (interactors.Proxy, error) {
+	args := blockchain.ArgsProxy{
+		ProxyURL:            gateway,
+		Client:              nil,
+		SameScState:         false,
+		ShouldBeSynced:      false,
+		FinalityCheck:       false,
+		CacheExpirationTime: time.Minute,
+		EntityType:          core.Proxy,
+	}
+
+	return blockchain.NewProxy(args)
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/multiversx/mx-chain-crypto-go/signing"
+	"github.com/multiversx/mx-chain-crypto-go/signing/ed25519"
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-sdk-go/blockchain/cryptoProvider"
+	"github.com/multiversx/mx-sdk-go/builders"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"github.com/multiversx/mx-sdk-go/workflows"
+)
+
+// sendBunchSize is the number of transactions sent to the proxy in a single request
+const sendBunchSize = 100
+
+var (
+	walletKeyGen = signing.NewKeyGenerator(ed25519.NewEd25519())
+	log          = logger.GetOrCreate("common")
+)
+
+// TxCall is a transaction to be signed by SendTransactions. The data field gas is added on top of GasLimit.
+type TxCall struct {
+	Receiver string
+	Value    *big.Int
+	GasLimit uint64
+	Data     string
+}
+
+// SendTransactions signs the calls from the wallet with consecutive nonces, starting at the account nonce, and sends
+// them as a bunch. The hashes are returned in the order of the calls.
+func SendTransactions(proxy interactors.Proxy, skBytes []byte, calls []*TxCall) ([]string, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	holder, err := cryptoProvider.NewCryptoComponentsHolder(walletKeyGen, skBytes)
+	if err != nil {
+		return nil, err
+	}
+	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
+	if err != nil {
+		return nil, err
+	}
+	ti, err := interactors.NewTransactionInteractor(proxy, txBuilder)
+	if err != nil {
+		return nil, err
+	}
+
+	// netConfigs can be used multiple times (for example when sending multiple transactions) as to improve the
+	// responsiveness of the system
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	tx, _, err := proxy.(workflows.ProxyHandler).GetDefaultTransactionArguments(context.Background(), holder.GetAddressHandler(), netConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := tx.Nonce
+	for _, call := range calls {
+		currentTx := tx
+		currentTx.Nonce = nonce
+		currentTx.Receiver = call.Receiver
+		currentTx.Value = call.Value.String()
+		currentTx.Data = []byte(call.Data)
+		currentTx.GasLimit = call.GasLimit + netConfigs.GasPerDataByte*uint64(len(currentTx.Data))
+
+		err = ti.ApplyUserSignature(holder, &currentTx)
+		if err != nil {
+			return nil, err
+		}
+		ti.AddTransaction(&currentTx)
+
+		log.Info("generated tx",
+			"nonce", currentTx.Nonce,
+			"value", currentTx.Value,
+			"gasLimit", currentTx.GasLimit,
+			"sender", currentTx.Sender,
+			"receiver", currentTx.Receiver,
+			"data", call.Data)
+		nonce++
+	}
+
+	return ti.SendTransactionsAsBunch(context.Background(), sendBunchSize)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/queries"
+)
+
+const (
+	legacyDelegation = "legacy"
+	systemDelegation = "system"
+)
+
+// maxLegacyDelegators bounds the getUserAddress enumeration of the legacy contracts
+const maxLegacyDelegators = 100000
+
+// contractConfig holds the contract settings, the fields a contract kind does not have are left empty
+type contractConfig struct {
+	Owner                       string `json:"owner"`
+	ServiceFee                  string `json:"serviceFee"`
+	MaxDelegationCap            string `json:"maxDelegationCap"`
+	InitialOwnerFunds           string `json:"initialOwnerFunds,omitempty"`
+	AutomaticActivation         string `json:"automaticActivation,omitempty"`
+	WithDelegationCap           string `json:"withDelegationCap,omitempty"`
+	ChangeableServiceFee        string `json:"changeableServiceFee,omitempty"`
+	CheckCapOnReDelegateRewards string `json:"checkCapOnReDelegateRewards,omitempty"`
+	CreatedNonce                string `json:"createdNonce,omitempty"`
+	UnBondPeriodInEpochs        string `json:"unBondPeriodInEpochs,omitempty"`
+}
+
+// delegatorState holds the stake of a delegator. The values are in the smallest denomination.
+type delegatorState struct {
+	Address          string `json:"address"`
+	ActiveStake      string `json:"activeStake"`
+	UnStakedValue    string `json:"unStakedValue,omitempty"`
+	ClaimableRewards string `json:"claimableRewards"`
+}
+
+// contractState is the JSON dump of a delegation contract. A failed query does not stop the inspection, its error is
+// recorded under the function name.
+type contractState struct {
+	Contract         string              `json:"contract"`
+	Kind             string              `json:"kind"`
+	Config           contractConfig      `json:"config"`
+	TotalActiveStake string              `json:"totalActiveStake"`
+	TotalUnStaked    string              `json:"totalUnStaked"`
+	NumDelegators    int64               `json:"numDelegators"`
+	Nodes            map[string][]string `json:"nodes"`
+	Delegators       []*delegatorState   `json:"delegators"`
+	QueryErrors      map[string]string   `json:"queryErrors,omitempty"`
+
+	proxy interactors.Proxy
+}
+
+func inspectContract(proxy interactors.Proxy, contract string, delegators []string) (*contractState, error) {
+	address, err := data.NewAddressFromBech32String(contract)
+	if err != nil {
+		return nil, fmt.Errorf("%w for contract %s", err, contract)
+	}
+
+	state := &contractState{
+		Contract:    contract,
+		Kind:        legacyDelegation,
+		Nodes:       make(map[string][]string),
+		Delegators:  make([]*delegatorState, 0),
+		QueryErrors: make(map[string]string),
+		proxy:       proxy,
+	}
+	if queries.IsSystemDelegationContract(address.AddressBytes()) {
+		state.Kind = systemDelegation
+	}
+
+	owner, err := queries.GetContractOwner(proxy.(queries.ContractOwnerProxy), contract)
+	if err != nil {
+		state.QueryErrors["owner"] = err.Error()
+	} else {
+		state.Config.Owner, _ = data.NewAddressFromBytes(owner).AddressAsBech32String()
+	}
+
+	if state.Kind == systemDelegation {
+		state.readSystemConfig()
+		state.TotalUnStaked = state.queryBigInt("getTotalUnStaked")
+	} else {
+		state.Config.ServiceFee = state.queryBigInt("getServiceFee")
+		state.Config.MaxDelegationCap = state.queryBigInt("getTotalDelegationCap")
+		state.TotalUnStaked = state.queryBigInt("getTotalInactiveStake")
+	}
+	state.TotalActiveStake = state.queryBigInt("getTotalActiveStake")
+	state.NumDelegators = state.queryInt64("getNumUsers")
+	state.readNodes()
+	state.readDelegators(delegators)
+
+	return state, nil
+}
+
+// readSystemConfig decodes the getContractConfig response of a system delegation contract
+func (state *contractState) readSystemConfig() {
+	returnData := state.query("getContractConfig")
+	if len(returnData) < 10 {
+		return
+	}
+
+	state.Config.ServiceFee = big.NewInt(0).SetBytes(returnData[1]).String()
+	state.Config.MaxDelegationCap = big.NewInt(0).SetBytes(returnData[2]).String()
+	state.Config.InitialOwnerFunds = big.NewInt(0).SetBytes(returnData[3]).String()
+	state.Config.AutomaticActivation = string(returnData[4])
+	state.Config.WithDelegationCap = string(returnData[5])
+	state.Config.ChangeableServiceFee = string(returnData[6])
+	state.Config.CheckCapOnReDelegateRewards = string(returnData[7])
+	state.Config.CreatedNonce = big.NewInt(0).SetBytes(returnData[8]).String()
+	state.Config.UnBondPeriodInEpochs = big.NewInt(0).SetBytes(returnData[9]).String()
+}
+
+func (state *contractState) readNodes() {
+	nodeStates, err := queries.GetAllNodeStates(state.proxy.(queries.VMQueryProxy), state.Contract, state.Contract)
+	if err != nil {
+		state.QueryErrors["getAllNodeStates"] = err.Error()
+		return
+	}
+
+	for key, nodeState := range nodeStates {
+		state.Nodes[nodeState] = append(state.Nodes[nodeState], key)
+	}
+	for _, stateKeys := range state.Nodes {
+		sort.Strings(stateKeys)
+	}
+}
+
+// readDelegators reads the stake of the provided delegators. The legacy contracts can enumerate their delegators, the
+// system ones only get the owner added to the provided list.
+func (state *contractState) readDelegators(delegators []string) {
+	addresses := make([]string, 0, len(delegators)+1)
+	if len(state.Config.Owner) > 0 {
+		addresses = append(addresses, state.Config.Owner)
+	}
+	if state.Kind == legacyDelegation {
+		addresses = append(addresses, state.enumerateLegacyDelegators()...)
+	}
+	addresses = append(addresses, delegators...)
+
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		if len(address) == 0 || seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		delegator, err := data.NewAddressFromBech32String(address)
+		if err != nil {
+			state.QueryErrors["delegator "+address] = err.Error()
+			continue
+		}
+
+		unStakedFunction := "getUserUnStakedValue"
+		if state.Kind == legacyDelegation {
+			unStakedFunction = "getUserInactiveStake"
+		}
+		state.Delegators = append(state.Delegators, &delegatorState{
+			Address:          address,
+			ActiveStake:      state.queryBigInt("getUserActiveStake", delegator.AddressBytes()),
+			UnStakedValue:    state.queryBigInt(unStakedFunction, delegator.AddressBytes()),
+			ClaimableRewards: state.queryBigInt("getClaimableRewards", delegator.AddressBytes()),
+		})
+	}
+}
+
+// enumerateLegacyDelegators reads the delegator addresses by their ids, which go from 1 to the number of users
+func (state *contractState) enumerateLegacyDelegators() []string {
+	numDelegators := state.NumDelegators
+	if numDelegators > maxLegacyDelegators {
+		numDelegators = maxLegacyDelegators
+	}
+
+	addresses := make([]string, 0, numDelegators)
+	for id := int64(1); id <= numDelegators; id++ {
+		returnData := state.query("getUserAddress", big.NewInt(id).Bytes())
+		if len(returnData) == 0 || len(returnData[0]) == 0 {
+			continue
+		}
+
+		address, err := data.NewAddressFromBytes(returnData[0]).AddressAsBech32String()
+		if err == nil {
+			addresses = append(addresses, address)
+		}
+	}
+
+	return addresses
+}
+
+// query runs the view function, recording its error if any. The error key holds the first argument, if provided, so
+// the per delegator errors do not overwrite each other.
+func (state *contractState) query(funcName string, args ...[]byte) [][]byte {
+	returnData, err := queries.ExecuteVMQuery(state.proxy.(queries.VMQueryProxy), state.Contract, state.Contract, funcName, args...)
+	if err != nil {
+		errorKey := funcName
+		if len(args) > 0 {
+			errorKey = fmt.Sprintf("%s@%x", funcName, args[0])
+		}
+		state.QueryErrors[errorKey] = err.Error()
+
+		return nil
+	}
+
+	return returnData
+}
+
+func (state *contractState) queryBigInt(funcName string, args ...[]byte) string {
+	returnData := state.query(funcName, args...)
+	if len(returnData) == 0 {
+		return ""
+	}
+
+	return big.NewInt(0).SetBytes(returnData[0]).String()
+}
+
+func (state *contractState) queryInt64(funcName string, args ...[]byte) int64 {
+	returnData := state.query(funcName, args...)
+	if len(returnData) == 0 {
+		return 0
+	}
+
+	return big.NewInt(0).SetBytes(returnData[0]).Int64()
+}
+
+func (state *contractState) logSummary() {
+	numNodes := make([]interface{}, 0, len(state.Nodes)*2)
+	nodeStates := make([]string, 0, len(state.Nodes))
+	for nodeState := range state.Nodes {
+		nodeStates = append(nodeStates, nodeState)
+	}
+	sort.Strings(nodeStates)
+	for _, nodeState := range nodeStates {
+		numNodes = append(numNodes, "nodes "+nodeState, len(state.Nodes[nodeState]))
+	}
+
+	log.Info("delegation contract",
+		"contract", state.Contract,
+		"kind", state.Kind,
+		"owner", state.Config.Owner,
+		"service fee", state.Config.ServiceFee,
+		"delegation cap", state.Config.MaxDelegationCap)
+	log.Info("stake",
+		"total active", state.TotalActiveStake,
+		"total unstaked", state.TotalUnStaked,
+		"num delegators", state.NumDelegators,
+		"num delegators inspected", len(state.Delegators))
+	if len(numNodes) > 0 {
+		log.Info("nodes", numNodes...)
+	}
+	if len(state.QueryErrors) > 0 {
+		log.Warn("some queries failed, see queryErrors in the output file", "num failed", len(state.QueryErrors))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"strings"
+
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-sdk-go/examples"
+	"v1/common"
+)
+
+const outputFilename = "./delegationState.json"
+
+var log = logger.GetOrCreate("delegationInspector")
+
+// delegationInspector is read only: it queries the full state of a legacy or system delegation contract, writes it to
+// a JSON file and prints a summary
+func main() {
+	contract := flag.String("contract", "", "the delegation contract, either a legacy or a system delegation contract, required")
+	output := flag.String("output", outputFilename, "the JSON file the contract state is written to")
+	delegators := flag.String("delegators", "", "comma separated delegator addresses to inspect")
+	delegatorsFile := flag.String("delegators-file", "", "a file with one delegator address per line to inspect. "+
+		"The system delegation contracts can not list their delegators so they have to be provided")
+	flag.Parse()
+
+	if len(*contract) == 0 {
+		panic("no contract provided, use -contract")
+	}
+
+	addresses := strings.Split(*delegators, ",")
+	if len(*delegatorsFile) > 0 {
+		fileAddresses, err := readAddressesFile(*delegatorsFile)
+		if err != nil {
+			panic(err)
+		}
+		addresses = append(addresses, fileAddresses...)
+	}
+
+	proxy, err := common.NewProxy(examples.TestnetGateway)
+	if err != nil {
+		panic(err)
+	}
+	state, err := inspectContract(proxy, *contract, addresses)
+	if err != nil {
+		panic(err)
+	}
+
+	err = common.SaveJSONFile(*output, state)
+	if err != nil {
+		panic(err)
+	}
+
+	state.logSummary()
+	log.Info("contract state written", "file", *output)
+}
+
+func readAddressesFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	addresses := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			addresses = append(addresses, line)
+		}
+	}
+
+	return addresses, scanner.Err()
+}
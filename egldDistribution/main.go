@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"time"
 
 	"github.com/multiversx/mx-chain-crypto-go/signing"
 	"github.com/multiversx/mx-chain-crypto-go/signing/ed25519"
 	logger "github.com/multiversx/mx-chain-logger-go"
-	"github.com/multiversx/mx-sdk-go/blockchain"
 	"github.com/multiversx/mx-sdk-go/blockchain/cryptoProvider"
 	"github.com/multiversx/mx-sdk-go/builders"
 	"github.com/multiversx/mx-sdk-go/core"
@@ -16,6 +16,8 @@ import (
 	"github.com/multiversx/mx-sdk-go/examples"
 	"github.com/multiversx/mx-sdk-go/interactors"
 	"github.com/multiversx/mx-sdk-go/workflows"
+	"v1/common"
+	"v1/keys"
 )
 
 const walletFilename = "./erd1q2yzhcy8nwq778v23j7hgdcnsa4pmlwjl0jwr9v86gyff4vr3sdqyyg49s.pem"
@@ -201,11 +203,17 @@ func main() {
 
 	proxy := createTestnetProxy()
 
+	walletFile := flag.String("wallet", walletFilename, "the wallet PEM or JSON keystore file")
+	passwordFile := flag.String("password-file", "", "the file holding the keystore password, "+
+		"defaults to the "+keys.PasswordEnvVariable+" variable or an interactive prompt")
+	flag.Parse()
+
 	wallet := interactors.NewWallet()
-	skBytes, err := wallet.LoadPrivateKeyFromPemFile(walletFilename)
+	skBytes, err := keys.LoadWalletKey(*walletFile, keys.NewPasswordProvider(*passwordFile, nil))
 	if err != nil {
 		panic(err)
 	}
+	defer keys.Wipe(skBytes)
 
 	// Generate address from private key
 	ownerAddress, err := wallet.GetAddressFromPrivateKey(skBytes)
@@ -260,21 +268,12 @@ func checkForDuplicates() {
 }
 
 func createTestnetProxy() interactors.Proxy {
-	args := blockchain.ArgsProxy{
-		ProxyURL:            examples.TestnetGateway,
-		Client:              nil,
-		SameScState:         false,
-		ShouldBeSynced:      false,
-		FinalityCheck:       false,
-		CacheExpirationTime: time.Minute,
-		EntityType:          core.Proxy,
-	}
-	ep, err := blockchain.NewProxy(args)
+	proxy, err := common.NewProxy(examples.TestnetGateway)
 	if err != nil {
 		panic(err)
 	}
 
-	return ep
+	return proxy
 }
 
 func generateAndSendMintEgldTx(
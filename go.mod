@@ -8,6 +8,8 @@ require (
 	github.com/multiversx/mx-chain-go v1.6.7
 	github.com/multiversx/mx-chain-logger-go v1.0.13
 	github.com/multiversx/mx-sdk-go v1.3.11
+	golang.org/x/crypto v0.16.0
+	golang.org/x/term v0.15.0
 )
 
 require (
@@ -28,7 +30,6 @@ require (
 	github.com/pelletier/go-toml v1.9.3 // indirect
 	github.com/syndtr/goleveldb v1.0.1-0.20220721030215-126854af5e6d // indirect
 	github.com/tyler-smith/go-bip39 v1.1.0 // indirect
-	golang.org/x/crypto v0.16.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
 )
@@ -0,0 +1,213 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const blsContainerVersion = 1
+const blsContainerKind = "blsKeys"
+const blsContainerCipher = "aes-256-gcm"
+const blsContainerKDF = "scrypt"
+const pemPrivateKeyHeader = "PRIVATE KEY for "
+
+// the scrypt parameters match the ones used by the MultiversX wallet keystore files
+const (
+	scryptN     = 4096
+	scryptR     = 8
+	scryptP     = 1
+	scryptDKLen = 32
+	saltLength  = 32
+)
+
+// ErrWrongPassword signals that the encrypted BLS container could not be opened with the provided password
+var ErrWrongPassword = errors.New("wrong password or corrupted BLS keys container")
+
+// blsContainer is the JSON encrypted container holding the content of an all.pem file
+type blsContainer struct {
+	Version int              `json:"version"`
+	Kind    string           `json:"kind"`
+	Crypto  blsContainerData `json:"crypto"`
+}
+
+type blsContainerData struct {
+	Cipher     string                `json:"cipher"`
+	CipherText string                `json:"ciphertext"`
+	Nonce      string                `json:"nonce"`
+	KDF        string                `json:"kdf"`
+	KDFParams  blsContainerKDFParams `json:"kdfparams"`
+}
+
+type blsContainerKDFParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// LoadBlsKeys loads the BLS private & public keys either from a PEM file or from an encrypted BLS container. The
+// private keys are returned hex encoded, as they are stored in the PEM file.
+func LoadBlsKeys(filename string, passwords *PasswordProvider) ([][]byte, []string, error) {
+	buff, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer Wipe(buff)
+
+	if !isJSON(buff) {
+		return parseBlsPem(buff)
+	}
+
+	password, err := passwords.Password(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pemData, err := DecryptBlsKeys(buff, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w for %s", err, filename)
+	}
+	defer Wipe(pemData)
+
+	return parseBlsPem(pemData)
+}
+
+// EncryptBlsKeys encrypts the content of a BLS keys PEM file into a JSON container
+func EncryptBlsKeys(pemData []byte, password string) ([]byte, error) {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	kdfParams := blsContainerKDFParams{
+		N:     scryptN,
+		R:     scryptR,
+		P:     scryptP,
+		DKLen: scryptDKLen,
+		Salt:  hex.EncodeToString(salt),
+	}
+	aead, err := newContainerCipher(password, salt, kdfParams)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	container := &blsContainer{
+		Version: blsContainerVersion,
+		Kind:    blsContainerKind,
+		Crypto: blsContainerData{
+			Cipher:     blsContainerCipher,
+			CipherText: hex.EncodeToString(aead.Seal(nil, nonce, pemData, nil)),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        blsContainerKDF,
+			KDFParams:  kdfParams,
+		},
+	}
+
+	return json.MarshalIndent(container, "", "  ")
+}
+
+// DecryptBlsKeys returns the PEM content stored in the encrypted container. The caller should wipe the result.
+func DecryptBlsKeys(containerData []byte, password string) ([]byte, error) {
+	container := &blsContainer{}
+	err := json.Unmarshal(containerData, container)
+	if err != nil {
+		return nil, err
+	}
+	if container.Version != blsContainerVersion || container.Kind != blsContainerKind {
+		return nil, fmt.Errorf("unsupported BLS keys container %s version %d", container.Kind, container.Version)
+	}
+	if container.Crypto.Cipher != blsContainerCipher || container.Crypto.KDF != blsContainerKDF {
+		return nil, fmt.Errorf("unsupported BLS keys container cipher %s with %s", container.Crypto.Cipher, container.Crypto.KDF)
+	}
+
+	salt, err := hex.DecodeString(container.Crypto.KDFParams.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(container.Crypto.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := hex.DecodeString(container.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newContainerCipher(password, salt, container.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid BLS keys container nonce length %d", len(nonce))
+	}
+
+	pemData, err := aead.Open(nil, nonce, cipherText, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	return pemData, nil
+}
+
+func newContainerCipher(password string, salt []byte, kdfParams blsContainerKDFParams) (cipher.AEAD, error) {
+	derivedKey, err := scrypt.Key([]byte(password), salt, kdfParams.N, kdfParams.R, kdfParams.P, kdfParams.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(derivedKey)
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// parseBlsPem reads the "PRIVATE KEY for <public key>" blocks, same as core.LoadAllKeysFromPemFile but from memory
+func parseBlsPem(buff []byte) ([][]byte, []string, error) {
+	privateKeys := make([][]byte, 0)
+	publicKeys := make([]string, 0)
+
+	buff = bytes.TrimSpace(buff)
+	for len(buff) > 0 {
+		var block *pem.Block
+		block, buff = pem.Decode(buff)
+		if block == nil {
+			return nil, nil, errors.New("invalid BLS keys PEM data")
+		}
+		buff = bytes.TrimSpace(buff)
+
+		if !strings.HasPrefix(block.Type, pemPrivateKeyHeader) {
+			return nil, nil, fmt.Errorf("missing '%s' in block type", pemPrivateKeyHeader)
+		}
+
+		privateKeys = append(privateKeys, block.Bytes)
+		publicKeys = append(publicKeys, block.Type[len(pemPrivateKeyHeader):])
+	}
+
+	if len(privateKeys) == 0 {
+		return nil, nil, errors.New("empty BLS keys PEM data")
+	}
+
+	return privateKeys, publicKeys, nil
+}
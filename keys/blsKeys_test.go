@@ -0,0 +1,115 @@
+package keys
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func createBlsPem(keys map[string]string) []byte {
+	pemData := make([]byte, 0)
+	for publicKey, privateKey := range keys {
+		pemData = append(pemData, pem.EncodeToMemory(&pem.Block{
+			Type:  pemPrivateKeyHeader + publicKey,
+			Bytes: []byte(privateKey),
+		})...)
+	}
+
+	return pemData
+}
+
+func writeFile(t *testing.T, filename string, content []byte) string {
+	err := os.WriteFile(filename, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return filename
+}
+
+func TestBlsContainerRoundTrip(t *testing.T) {
+	pemData := createBlsPem(map[string]string{"aa01": "0101"})
+
+	first, err := EncryptBlsKeys(pemData, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := EncryptBlsKeys(pemData, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("two encryptions of the same keys should use different salts")
+	}
+
+	decrypted, err := DecryptBlsKeys(second, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, pemData) {
+		t.Errorf("decrypted %q, expected %q", decrypted, pemData)
+	}
+
+	_, err = DecryptBlsKeys(first, "Password")
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword, got %v", err)
+	}
+}
+
+func TestLoadBlsContainersWithTheirOwnPasswords(t *testing.T) {
+	// two operators encrypted their containers with different passwords, the second one is given its own password file
+	dir := t.TempDir()
+	firstContainer, err := EncryptBlsKeys(createBlsPem(map[string]string{"aa01": "0101"}), "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondContainer, err := EncryptBlsKeys(createBlsPem(map[string]string{"bb02": "0202"}), "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstFile := writeFile(t, filepath.Join(dir, "first.json"), firstContainer)
+	secondFile := writeFile(t, filepath.Join(dir, "second.json"), secondContainer)
+	sharedPasswordFile := writeFile(t, filepath.Join(dir, "shared.txt"), []byte("first\n"))
+	secondPasswordFile := writeFile(t, filepath.Join(dir, "second.txt"), []byte("second\n"))
+
+	_, _, err = LoadBlsKeys(secondFile, NewPasswordProvider(sharedPasswordFile, nil))
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("the shared password should not open the second container, got %v", err)
+	}
+
+	passwords := NewPasswordProvider(sharedPasswordFile, PasswordFiles{secondFile: secondPasswordFile})
+	loaded := make(map[string][]byte)
+	for _, filename := range []string{firstFile, secondFile} {
+		privateKeys, publicKeys, errLoad := LoadBlsKeys(filename, passwords)
+		if errLoad != nil {
+			t.Fatalf("%s: %v", filename, errLoad)
+		}
+		for i := range publicKeys {
+			loaded[publicKeys[i]] = privateKeys[i]
+		}
+	}
+
+	expected := map[string][]byte{"aa01": []byte("0101"), "bb02": []byte("0202")}
+	if !reflect.DeepEqual(loaded, expected) {
+		t.Errorf("loaded %q, expected %q", loaded, expected)
+	}
+}
+
+func TestLoadBlsKeysFromAnInvalidPem(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"garbage.pem":   []byte("not a PEM file"),
+		"no-header.pem": pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("01")}),
+	} {
+		// a PEM file is read without asking for a password, so a provider without any source is enough
+		_, _, err := LoadBlsKeys(writeFile(t, filepath.Join(dir, name), content), NewPasswordProvider("", nil))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package keys
+
+import (
+	"encoding/hex"
+
+	"github.com/multiversx/mx-chain-crypto-go/signing"
+	"github.com/multiversx/mx-chain-crypto-go/signing/mcl"
+	"github.com/multiversx/mx-chain-crypto-go/signing/mcl/singlesig"
+)
+
+// BlsSignatureLength is the length of a BLS signature, in bytes
+const BlsSignatureLength = 48
+
+var (
+	blsKeyGen       = signing.NewKeyGenerator(mcl.NewSuiteBLS12())
+	blsSingleSigner = singlesig.NewBlsSigner()
+)
+
+// SignWithBlsKey signs the message with the hex encoded BLS private key, as read from a validators PEM file or an
+// encrypted BLS container. It is used for the proofs of possession sent along with the staked keys.
+func SignWithBlsKey(hexPrivateKey []byte, message []byte) ([]byte, error) {
+	decodedSk, err := hex.DecodeString(string(hexPrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(decodedSk)
+
+	blsKey, err := blsKeyGen.PrivateKeyFromByteArray(decodedSk)
+	if err != nil {
+		return nil, err
+	}
+
+	return blsSingleSigner.Sign(blsKey, message)
+}
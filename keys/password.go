@@ -0,0 +1,160 @@
+package keys
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// PasswordEnvVariable is the environment variable checked for the keys password when no password file is provided
+const PasswordEnvVariable = "MVX_KEYS_PASSWORD"
+
+// PasswordFilesUsage is the help of the flag collecting the PasswordFiles
+const PasswordFilesUsage = "a keyFile=passwordFile pair for a key file whose password differs from the shared one, can be repeated"
+
+// PasswordFiles maps a key file to the file holding its own password. It implements flag.Value, each value being a
+// keyFile=passwordFile pair.
+type PasswordFiles map[string]string
+
+// String returns the pairs, sorted by key file
+func (pf PasswordFiles) String() string {
+	pairs := make([]string, 0, len(pf))
+	for keyFile, passwordFile := range pf {
+		pairs = append(pairs, keyFile+"="+passwordFile)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Set adds a keyFile=passwordFile pair
+func (pf PasswordFiles) Set(value string) error {
+	keyFile, passwordFile, found := strings.Cut(value, "=")
+	if !found || len(keyFile) == 0 || len(passwordFile) == 0 {
+		return fmt.Errorf("invalid password file pair %q, expected keyFile=passwordFile", value)
+	}
+
+	pf[filepath.Clean(keyFile)] = passwordFile
+
+	return nil
+}
+
+// PasswordProvider supplies the passwords used to decrypt keystore files & encrypted BLS containers. A key file listed
+// in the password files uses its own password file. The other key files share a single password, read from the
+// password file if one is set, then from the PasswordEnvVariable and, as a last resort, from an interactive prompt.
+// Every password is read only once per run.
+type PasswordProvider struct {
+	mut            sync.Mutex
+	passwordFile   string
+	passwordFiles  PasswordFiles
+	sharedPassword string
+	sharedLoaded   bool
+	filePasswords  map[string]string
+}
+
+// NewPasswordProvider creates a password provider, the password file can be empty and the password files nil
+func NewPasswordProvider(passwordFile string, passwordFiles PasswordFiles) *PasswordProvider {
+	return &PasswordProvider{
+		passwordFile:  passwordFile,
+		passwordFiles: passwordFiles,
+		filePasswords: make(map[string]string),
+	}
+}
+
+// Password returns the password of the target key file, reading it on the first call for that password. For the shared
+// password the target is only used in the prompt message.
+func (pp *PasswordProvider) Password(target string) (string, error) {
+	pp.mut.Lock()
+	defer pp.mut.Unlock()
+
+	keyFile := filepath.Clean(target)
+	passwordFile, found := pp.passwordFiles[keyFile]
+	if found {
+		return pp.filePassword(keyFile, passwordFile)
+	}
+
+	if pp.sharedLoaded {
+		return pp.sharedPassword, nil
+	}
+
+	password, err := pp.readPassword(target)
+	if err != nil {
+		return "", err
+	}
+
+	pp.sharedPassword = password
+	pp.sharedLoaded = true
+
+	return pp.sharedPassword, nil
+}
+
+func (pp *PasswordProvider) filePassword(keyFile string, passwordFile string) (string, error) {
+	password, found := pp.filePasswords[keyFile]
+	if found {
+		return password, nil
+	}
+
+	password, err := readPasswordFile(passwordFile)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s", err, keyFile)
+	}
+	pp.filePasswords[keyFile] = password
+
+	return password, nil
+}
+
+func (pp *PasswordProvider) readPassword(target string) (string, error) {
+	if len(pp.passwordFile) > 0 {
+		return readPasswordFile(pp.passwordFile)
+	}
+
+	password, found := os.LookupEnv(PasswordEnvVariable)
+	if found {
+		return password, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no password provided for %s: set a password file, the %s variable or run in a terminal",
+			target, PasswordEnvVariable)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "password for %s: ", target)
+	buff, err := term.ReadPassword(int(os.Stdin.Fd()))
+	_, _ = fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	defer Wipe(buff)
+
+	return string(buff), nil
+}
+
+func readPasswordFile(passwordFile string) (string, error) {
+	buff, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", fmt.Errorf("%w while reading the password file", err)
+	}
+	defer Wipe(buff)
+
+	return string(bytes.TrimRight(buff, "\r\n")), nil
+}
+
+// Wipe overwrites the provided buffer with zeros
+func Wipe(buff []byte) {
+	for i := range buff {
+		buff[i] = 0
+	}
+}
+
+// WipeAll overwrites all the provided buffers with zeros
+func WipeAll(buffs [][]byte) {
+	for _, buff := range buffs {
+		Wipe(buff)
+	}
+}
@@ -0,0 +1,86 @@
+package keys
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPasswordFilesFlag(t *testing.T) {
+	passwordFiles := PasswordFiles{}
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.Var(passwordFiles, "file-password", PasswordFilesUsage)
+
+	err := flagSet.Parse([]string{"-file-password", "./keys/b.json=b.txt", "-file-password", "a.json=a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passwordFiles.String() != "a.json=a.txt,keys/b.json=b.txt" {
+		t.Errorf("unexpected password files %s", passwordFiles.String())
+	}
+
+	for _, value := range []string{"a.json", "=a.txt", "a.json="} {
+		err = passwordFiles.Set(value)
+		if err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestSharedPasswordSources(t *testing.T) {
+	passwordFile := writeFile(t, filepath.Join(t.TempDir(), "password.txt"), []byte("from file\r\n"))
+	t.Setenv(PasswordEnvVariable, "from env")
+
+	password, err := NewPasswordProvider(passwordFile, nil).Password("wallet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "from file" {
+		t.Errorf("the password file should come before the environment, got %q", password)
+	}
+
+	password, err = NewPasswordProvider("", nil).Password("wallet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "from env" {
+		t.Errorf("got %q, expected the environment password", password)
+	}
+
+	_, err = NewPasswordProvider(passwordFile+".missing", nil).Password("wallet.json")
+	if err == nil {
+		t.Error("expected an error for a missing password file")
+	}
+}
+
+func TestPasswordsAreCachedPerKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	sharedFile := writeFile(t, filepath.Join(dir, "shared.txt"), []byte("shared"))
+	ownFile := writeFile(t, filepath.Join(dir, "own.txt"), []byte("own"))
+	passwords := NewPasswordProvider(sharedFile, PasswordFiles{"wallets/b.json": ownFile})
+
+	read := func(target string) string {
+		password, err := passwords.Password(target)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		return password
+	}
+
+	if read("wallets/a.json") != "shared" || read("./wallets/b.json") != "own" {
+		t.Fatal("each key file should get its password")
+	}
+
+	// the passwords were read once, changing the files does not change them
+	writeFile(t, sharedFile, []byte("changed"))
+	err := os.Remove(ownFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read("wallets/c.json") != "shared" || read("wallets/b.json") != "own" {
+		t.Error("the passwords should be cached for the run")
+	}
+}
@@ -0,0 +1,34 @@
+package keys
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+// LoadWalletKey loads a wallet private key either from a PEM file or from a MultiversX JSON keystore file. The format
+// is detected from the file content, the password is only requested for keystore files.
+func LoadWalletKey(filename string, passwords *PasswordProvider) ([]byte, error) {
+	buff, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(buff)
+
+	wallet := interactors.NewWallet()
+	if !isJSON(buff) {
+		return wallet.LoadPrivateKeyFromPemData(buff)
+	}
+
+	password, err := passwords.Password(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet.LoadPrivateKeyFromJsonFile(filename, password)
+}
+
+func isJSON(buff []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(buff), []byte("{"))
+}
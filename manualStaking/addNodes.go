@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/keys"
+)
+
+const maxKeysInNodesTx = 50
+
+// runAddNodes adds the BLS keys of each account to an existing delegation contract owned by that account and then
+// stakes them from the contract's funds. No mint is done, the accounts only pay the transaction fees.
+func runAddNodes(args []string) {
+	flags := flag.NewFlagSet(commandAddNodes, flag.ExitOnError)
+	contract := flags.String("contract", "", "the delegation contract for all accounts, defaults to the contract recorded for each owner")
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	readStakeInfo := readDirStakeInfo()
+	contracts := resolveDelegationContracts(readStakeInfo, *contract)
+	verifyBlsKeysForMessage(readStakeInfo, func(si *stakeInfo) []byte {
+		return decodeBech32Address(contracts[si.walletKey.bech32Address])
+	})
+
+	proxy := createTestnetProxy()
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	failed := processStakeInfosInParallel(readStakeInfo, *numWorkers, func(si *stakeInfo) {
+		processAddNodes(si, contracts[si.walletKey.bech32Address], proxy, netConfigs)
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("processing failed for %d account(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+// resolveDelegationContracts returns the owner -> contract mapping either from the provided contract address or from
+// the mapping file written when the contracts were created
+func resolveDelegationContracts(readStakeInfo []*stakeInfo, contract string) map[string]string {
+	contracts := make(map[string]string)
+	if len(contract) > 0 {
+		for _, si := range readStakeInfo {
+			contracts[si.walletKey.bech32Address] = contract
+		}
+
+		return contracts
+	}
+
+	buff, err := os.ReadFile(path.Join(keysDir, delegationContractsFilename))
+	requireNilErr(err)
+
+	err = json.Unmarshal(buff, &contracts)
+	requireNilErr(err)
+
+	for _, si := range readStakeInfo {
+		_, found := contracts[si.walletKey.bech32Address]
+		if !found {
+			panic(fmt.Sprintf("no delegation contract recorded for owner %s", si.walletKey.bech32Address))
+		}
+	}
+
+	return contracts
+}
+
+func processAddNodes(si *stakeInfo, contractAddress string, proxy interactors.Proxy, netConfig *data.NetworkConfig) {
+	log.Info("")
+	log.Info("############### adding nodes for " + si.walletKey.bech32Address + " ###############")
+	requireContractOwner(proxy, contractAddress, si.walletKey)
+	addDelegationNodes(si, contractAddress, proxy, netConfig)
+	stakeDelegationNodes(si, contractAddress, proxy, netConfig)
+}
+
+// addDelegationNodes sends the addNodes calls with the account's keys, each signed over the contract address
+func addDelegationNodes(si *stakeInfo, contractAddress string, proxy interactors.Proxy, netConfig *data.NetworkConfig) {
+	log.Info("add nodes", "owner", si.walletKey.bech32Address, "contract", contractAddress, "num keys", len(si.blsPublicKeys))
+
+	contractAddressBytes := decodeBech32Address(contractAddress)
+	for start := 0; start < len(si.blsPublicKeys); start += maxKeysInNodesTx {
+		end := start + maxKeysInNodesTx
+		if end > len(si.blsPublicKeys) {
+			end = len(si.blsPublicKeys)
+		}
+
+		txData := "addNodes"
+		for blsIndex := start; blsIndex < end; blsIndex++ {
+			signature, err := keys.SignWithBlsKey(si.blsPrivateKeys[blsIndex], contractAddressBytes)
+			requireNilErr(err)
+
+			txData += fmt.Sprintf("@%s@%x", si.blsPublicKeys[blsIndex], signature)
+		}
+
+		sendTransactionAndWait(proxy, si.walletKey, netConfig, contractAddress, big.NewInt(0), nodesTxGasLimit(end-start), txData)
+	}
+}
+
+// stakeDelegationNodes sends the stakeNodes calls for all the account's keys, already added in the contract
+func stakeDelegationNodes(si *stakeInfo, contractAddress string, proxy interactors.Proxy, netConfig *data.NetworkConfig) {
+	for start := 0; start < len(si.blsPublicKeys); start += maxKeysInNodesTx {
+		end := start + maxKeysInNodesTx
+		if end > len(si.blsPublicKeys) {
+			end = len(si.blsPublicKeys)
+		}
+
+		txData := "stakeNodes@" + strings.Join(si.blsPublicKeys[start:end], "@")
+		sendTransactionAndWait(proxy, si.walletKey, netConfig, contractAddress, big.NewInt(0), nodesTxGasLimit(end-start), txData)
+	}
+}
+
+func nodesTxGasLimit(numKeys int) uint64 {
+	return baseStakeGas + uint64(numKeys)*stakeGasPerNode
+}
+
+func decodeBech32Address(bech32Address string) []byte {
+	address, err := data.NewAddressFromBech32String(bech32Address)
+	requireNilErr(err)
+
+	return address.AddressBytes()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"v1/keys"
+)
+
+// blsKeyMismatch describes a BLS key from all.pem that can not be used for staking
+type blsKeyMismatch struct {
+	owner     string
+	index     int
+	publicKey string
+	reason    string
+}
+
+// verifyBlsKeys checks, for every account, that each BLS private key matches the listed public key and that the
+// proof of possession over the owner address verifies against the listed public key. All mismatches are reported
+// before aborting.
+func verifyBlsKeys(readStakeInfo []*stakeInfo) {
+	verifyBlsKeysForMessage(readStakeInfo, func(si *stakeInfo) []byte {
+		return si.walletKey.address.AddressBytes()
+	})
+}
+
+// verifyBlsKeysForMessage is the same as verifyBlsKeys but the proof of possession is done over the message returned
+// for each account, as when the keys are added in a delegation contract
+func verifyBlsKeysForMessage(readStakeInfo []*stakeInfo, messageFor func(si *stakeInfo) []byte) {
+	mismatches := make([]*blsKeyMismatch, 0)
+	for _, si := range readStakeInfo {
+		message := messageFor(si)
+		for i := range si.blsPublicKeys {
+			reason := verifyBlsKey(si.blsPrivateKeys[i], si.blsPublicKeys[i], message)
+			if len(reason) == 0 {
+				continue
+			}
+
+			mismatches = append(mismatches, &blsKeyMismatch{
+				owner:     si.walletKey.bech32Address,
+				index:     i,
+				publicKey: si.blsPublicKeys[i],
+				reason:    reason,
+			})
+		}
+	}
+
+	if len(mismatches) == 0 {
+		log.Info("verified BLS keys", "num accounts", len(readStakeInfo))
+		return
+	}
+
+	for _, mismatch := range mismatches {
+		log.Error("invalid BLS key",
+			"owner", mismatch.owner,
+			"index", mismatch.index,
+			"public key", mismatch.publicKey,
+			"reason", mismatch.reason)
+	}
+
+	panic(fmt.Sprintf("found %d invalid BLS key(s)", len(mismatches)))
+}
+
+func verifyBlsKey(hexPrivateKey []byte, hexPublicKey string, message []byte) string {
+	decodedSk, err := hex.DecodeString(string(hexPrivateKey))
+	if err != nil {
+		return "private key is not hex encoded: " + err.Error()
+	}
+	defer keys.Wipe(decodedSk)
+
+	sk, err := blsKeyGen.PrivateKeyFromByteArray(decodedSk)
+	if err != nil {
+		return "invalid private key: " + err.Error()
+	}
+
+	derivedPkBytes, err := sk.GeneratePublic().ToByteArray()
+	if err != nil {
+		return "can not derive the public key: " + err.Error()
+	}
+	if hex.EncodeToString(derivedPkBytes) != hexPublicKey {
+		return "derived public key " + hex.EncodeToString(derivedPkBytes) + " does not match"
+	}
+
+	decodedPk, err := hex.DecodeString(hexPublicKey)
+	if err != nil {
+		return "public key is not hex encoded: " + err.Error()
+	}
+
+	pk, err := blsKeyGen.PublicKeyFromByteArray(decodedPk)
+	if err != nil {
+		return "invalid public key: " + err.Error()
+	}
+
+	signature, err := blsSingleSigner.Sign(sk, message)
+	if err != nil {
+		return "can not sign: " + err.Error()
+	}
+
+	err = blsSingleSigner.Verify(pk, message, signature)
+	if err != nil {
+		return "signature verification failed: " + err.Error()
+	}
+
+	return ""
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+	"v1/keys"
+)
+
+// runCreateDelegation deploys a new delegation contract for each account through createNewDelegationContract, with the
+// account's stake value as the initial owner deposit, and then adds the account's keys in it. The keys are staked only
+// if the contract already holds enough active stake, otherwise they remain added and wait for delegators.
+func runCreateDelegation(args []string) {
+	flags := flag.NewFlagSet(commandCreate, flag.ExitOnError)
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	readStakeInfo := readDirStakeInfo()
+	verifyBlsKeys(readStakeInfo)
+
+	proxy := createTestnetProxy()
+	cfg := readStakingConfig(proxy)
+	checkInitialDeposits(readStakeInfo, cfg)
+
+	sponsorWalletKeyAddress := loadWalletKeyAddress(resolveKeyFile("", sponsorWalletFilename, sponsorKeystoreFilename))
+	account, err := proxy.GetAccount(context.Background(), sponsorWalletKeyAddress.address)
+	requireNilErr(err)
+
+	log.Info("sponsor account", "address", sponsorWalletKeyAddress.bech32Address, "balance", account.Balance)
+
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	fundings := checkSponsorFunding(readStakeInfo, account.Balance, netConfigs, estimateNewDelegationFunding)
+
+	sponsorNonces := newSponsorNonceManager(proxy, sponsorWalletKeyAddress)
+	mints := processMints(readStakeInfo, proxy, sponsorWalletKeyAddress, sponsorNonces, fundings, netConfigs)
+	sponsorWalletKeyAddress.wipe()
+	failed := processStakeInfosInParallel(readStakeInfo, *numWorkers, func(si *stakeInfo) {
+		processNewDelegation(si, proxy, mints[si.walletKey.bech32Address], netConfigs, cfg)
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("processing failed for %d account(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+// checkInitialDeposits aborts if any account's stake value is below the minimum deposit for a new delegation contract
+func checkInitialDeposits(readStakeInfo []*stakeInfo, cfg *stakingConfig) {
+	numInvalid := 0
+	for _, si := range readStakeInfo {
+		if si.stakeValue.Cmp(cfg.minDeposit) >= 0 {
+			continue
+		}
+
+		log.Error("initial deposit is below the minimum deposit",
+			"owner", si.walletKey.bech32Address,
+			"stake value", si.stakeValue.String(),
+			"min deposit", cfg.minDeposit.String())
+		numInvalid++
+	}
+
+	if numInvalid > 0 {
+		panic(fmt.Sprintf("%d account(s) do not have a valid initial deposit", numInvalid))
+	}
+}
+
+func processNewDelegation(si *stakeInfo, proxy interactors.Proxy, mint *pendingMint, netConfig *data.NetworkConfig, cfg *stakingConfig) {
+	log.Info("")
+	log.Info("############### creating delegation contract for " + si.walletKey.bech32Address + " ###############")
+	err := mint.wait()
+	requireNilErr(err)
+	log.Info("mint confirmed", "owner", si.walletKey.bech32Address, "hash", mint.hash)
+
+	hash := createNewDelegationContract(si, proxy, netConfig)
+	contractAddress, err := extractDelegationContractAddress(proxy, hash, si.walletKey)
+	requireNilErr(err)
+	log.Info("found delegation contract", "owner", si.walletKey.bech32Address, "contract", contractAddress)
+	recordDelegationContract(si.walletKey.bech32Address, contractAddress)
+	configureDelegationContract(si, si.manifest.withoutFeeAndCap(), contractAddress, proxy, netConfig)
+
+	addDelegationNodes(si, contractAddress, proxy, netConfig)
+
+	totalActiveStake := getTotalActiveStake(proxy, contractAddress, si.walletKey.bech32Address)
+	required := big.NewInt(0).Mul(big.NewInt(int64(len(si.blsPublicKeys))), cfg.nodePrice)
+	if totalActiveStake.Cmp(required) < 0 {
+		log.Warn("not enough active stake in the contract, the nodes were added but not staked",
+			"contract", contractAddress,
+			"total active stake", totalActiveStake.String(),
+			"required", required.String())
+		return
+	}
+
+	stakeDelegationNodes(si, contractAddress, proxy, netConfig)
+}
+
+func createNewDelegationContract(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig) string {
+	serviceFee := feeString
+	if si.manifest.ServiceFee != nil {
+		serviceFee = common.HexBigInt(big.NewInt(int64(*si.manifest.ServiceFee)))
+	}
+
+	delegationCap := big.NewInt(0)
+	if len(si.manifest.TotalDelegationCap) > 0 {
+		var err error
+		delegationCap, err = si.manifest.totalDelegationCapValue()
+		requireNilErr(err)
+	}
+
+	log.Info("create new delegation contract",
+		"owner", si.walletKey.bech32Address,
+		"initial deposit", si.stakeValue.String(),
+		"delegation cap", delegationCap.String())
+
+	delegationManagerAddress, _ := data.NewAddressFromBytes(vm.DelegationManagerSCAddress).AddressAsBech32String()
+	txData := fmt.Sprintf("createNewDelegationContract@%s@%s", common.HexBigInt(delegationCap), serviceFee)
+
+	return sendTransactionAndWait(proxy, si.walletKey, netConfig, delegationManagerAddress, si.stakeValue, makeContractGas, txData)
+}
+
+func getTotalActiveStake(proxy interactors.Proxy, contractAddress string, caller string) *big.Int {
+	returnData, err := executeVMQuery(proxy, contractAddress, caller, "getTotalActiveStake")
+	requireNilErr(err)
+	if len(returnData) == 0 {
+		return big.NewInt(0)
+	}
+
+	return big.NewInt(0).SetBytes(returnData[0])
+}
+
+// estimateNewDelegationFunding estimates the funding for the create-delegation command: the contract creation with the
+// initial deposit, followed by the addNodes & stakeNodes calls
+func estimateNewDelegationFunding(si *stakeInfo, netConfig *data.NetworkConfig) *accountFunding {
+	gasPrice := big.NewInt(0).SetUint64(netConfig.MinGasPrice)
+
+	// each key adds its hex public key in both calls and, in addNodes, its hex signature, half as long as the key
+	nodesGas := uint64(0)
+	for start := 0; start < len(si.blsPublicKeys); start += maxKeysInNodesTx {
+		end := start + maxKeysInNodesTx
+		if end > len(si.blsPublicKeys) {
+			end = len(si.blsPublicKeys)
+		}
+
+		for _, txData := range []string{"addNodes", "stakeNodes"} {
+			dataLen := len(txData)
+			for blsIndex := start; blsIndex < end; blsIndex++ {
+				dataLen += 1 + len(si.blsPublicKeys[blsIndex])
+				if txData == "addNodes" {
+					dataLen += 1 + 2*keys.BlsSignatureLength
+				}
+			}
+			nodesGas += nodesTxGasLimit(end-start) + uint64(dataByteGasLimit*dataLen)
+		}
+	}
+
+	manifestWithoutFeeAndCap := si.manifest.withoutFeeAndCap()
+	configGas := uint64(0)
+	for _, call := range delegationConfigCalls(manifestWithoutFeeAndCap) {
+		configGas += delegationOwnerCallGas + uint64(dataByteGasLimit*len(call))
+	}
+
+	return &accountFunding{
+		owner:         si.walletKey.bech32Address,
+		stake:         si.stakeValue,
+		buffer:        oneELGD,
+		mintFee:       gasFee(mintGasLimit+uint64(dataByteGasLimit*len(mintData)), gasPrice),
+		stakeFees:     gasFee(nodesGas, gasPrice),
+		delegationFee: gasFee(makeContractGas, gasPrice),
+		configFees:    gasFee(configGas, gasPrice),
+	}
+}
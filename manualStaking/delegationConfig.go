@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+)
+
+const delegationOwnerCallGas = 2000000
+
+// configureDelegationContract applies the manifest settings on the delegation contract, one call at a time
+func configureDelegationContract(
+	si *stakeInfo,
+	manifest *accountManifest,
+	contractAddress string,
+	proxy interactors.Proxy,
+	netConfig *data.NetworkConfig,
+) {
+	log.Info("configure delegation contract", "owner", si.walletKey.bech32Address, "contract", contractAddress)
+
+	calls := delegationConfigCalls(manifest)
+	if len(calls) == 0 {
+		log.Info("nothing to configure", "contract", contractAddress)
+		return
+	}
+	requireContractOwner(proxy, contractAddress, si.walletKey)
+
+	for _, call := range calls {
+		sendTransactionAndWait(proxy, si.walletKey, netConfig, contractAddress, big.NewInt(0), delegationOwnerCallGas, call)
+	}
+}
+
+// delegationConfigCalls returns the data fields of the calls needed to apply the manifest settings
+func delegationConfigCalls(manifest *accountManifest) []string {
+	calls := make([]string, 0)
+	if manifest.hasMetaData() {
+		calls = append(calls, fmt.Sprintf("setMetaData@%s@%s@%s",
+			hex.EncodeToString([]byte(manifest.Name)),
+			hex.EncodeToString([]byte(manifest.Website)),
+			hex.EncodeToString([]byte(manifest.Identity))))
+	}
+	if manifest.AutomaticActivation != nil {
+		calls = append(calls, "setAutomaticActivation@"+hexBool(*manifest.AutomaticActivation))
+	}
+	if manifest.CheckCapOnReDelegateRewards != nil {
+		calls = append(calls, "setCheckCapOnReDelegateRewards@"+hexBool(*manifest.CheckCapOnReDelegateRewards))
+	}
+	if manifest.ServiceFee != nil {
+		calls = append(calls, fmt.Sprintf("changeServiceFee@%s", common.HexBigInt(big.NewInt(int64(*manifest.ServiceFee)))))
+	}
+	if len(manifest.TotalDelegationCap) > 0 {
+		delegationCap, err := manifest.totalDelegationCapValue()
+		requireNilErr(err)
+		calls = append(calls, fmt.Sprintf("modifyTotalDelegationCap@%s", common.HexBigInt(delegationCap)))
+	}
+
+	return calls
+}
+
+func hexBool(value bool) string {
+	if value {
+		return hex.EncodeToString([]byte("true"))
+	}
+
+	return hex.EncodeToString([]byte("false"))
+}
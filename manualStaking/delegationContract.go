@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+const delegationContractsFilename = "delegationContracts.json"
+const scDeployEventIdentifier = "SCDeploy"
+const addressLength = 32
+
+var okReturnCodeHex = hex.EncodeToString([]byte("ok"))
+var mutDelegationContractsFile sync.Mutex
+
+type transactionInfoProxy interface {
+	GetTransactionInfoWithResults(ctx context.Context, hash string) (*data.TransactionInfo, error)
+}
+
+// extractDelegationContractAddress decodes the address of the contract created by the provided transaction. The
+// SCDeploy event is checked first, then the return data of the smart contract results sent back to the owner.
+func extractDelegationContractAddress(proxy interactors.Proxy, hexTxHash string, owner *walletKeyAddress) (string, error) {
+	txInfo, err := proxy.(transactionInfoProxy).GetTransactionInfoWithResults(context.Background(), hexTxHash)
+	if err != nil {
+		return "", err
+	}
+
+	tx := txInfo.Data.Transaction
+	contractAddress := findDeployedAddressInLogs(tx.Logs)
+	if len(contractAddress) > 0 {
+		return contractAddress, nil
+	}
+
+	for _, scr := range tx.ScResults {
+		contractAddress = findDeployedAddressInLogs(scr.Logs)
+		if len(contractAddress) > 0 {
+			return contractAddress, nil
+		}
+	}
+
+	for _, scr := range tx.ScResults {
+		if scr.RcvAddr != owner.bech32Address {
+			continue
+		}
+
+		contractAddress, err = decodeAddressFromReturnData(scr.Data)
+		if err == nil {
+			return contractAddress, nil
+		}
+		log.Debug("smart contract result does not contain an address", "hash", scr.Hash, "data", scr.Data, "error", err)
+	}
+
+	return "", fmt.Errorf("no created contract address found in transaction %s", hexTxHash)
+}
+
+func findDeployedAddressInLogs(logs *transaction.ApiLogs) string {
+	if logs == nil {
+		return ""
+	}
+
+	for _, event := range logs.Events {
+		if event.Identifier == scDeployEventIdentifier && len(event.Address) > 0 {
+			return event.Address
+		}
+	}
+
+	return ""
+}
+
+// decodeAddressFromReturnData expects data in the @6f6b@<hex address> format
+func decodeAddressFromReturnData(scrData string) (string, error) {
+	parts := strings.Split(scrData, "@")
+	if len(parts) != 3 || parts[1] != okReturnCodeHex {
+		return "", errors.New("unexpected return data format")
+	}
+
+	addressBytes, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	if len(addressBytes) != addressLength {
+		return "", fmt.Errorf("invalid address length %d", len(addressBytes))
+	}
+
+	return data.NewAddressFromBytes(addressBytes).AddressAsBech32String()
+}
+
+// recordDelegationContract adds the owner -> contract entry to the mapping file stored in the keys directory
+func recordDelegationContract(owner string, contractAddress string) {
+	mutDelegationContractsFile.Lock()
+	defer mutDelegationContractsFile.Unlock()
+
+	filename := path.Join(keysDir, delegationContractsFilename)
+	contracts := make(map[string]string)
+
+	buff, err := os.ReadFile(filename)
+	if err == nil {
+		err = json.Unmarshal(buff, &contracts)
+		requireNilErr(err)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		requireNilErr(err)
+	}
+
+	contracts[owner] = contractAddress
+	writeJSONFile(filename, contracts)
+
+	log.Info("recorded delegation contract", "owner", owner, "contract", contractAddress, "file", filename)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+const signalErrorEventIdentifier = "signalError"
+const internalVMErrorsEventIdentifier = "internalVMErrors"
+
+// transactionFailedError is returned when a transaction was executed but did not succeed. The reasons are decoded
+// from the transaction's logs & smart contract results.
+type transactionFailedError struct {
+	hash    string
+	status  transaction.TxStatus
+	reasons []string
+}
+
+// Error returns the transaction hash, status and the decoded failure reasons
+func (err *transactionFailedError) Error() string {
+	if len(err.reasons) == 0 {
+		return fmt.Sprintf("transaction %s failed with status %s, no reason found", err.hash, err.status)
+	}
+
+	return fmt.Sprintf("transaction %s failed with status %s: %s", err.hash, err.status, strings.Join(err.reasons, "; "))
+}
+
+// newTransactionFailedError fetches the transaction with its results and decodes the failure reasons. The error is
+// still built, without reasons, if the transaction can not be fetched.
+func newTransactionFailedError(proxy interactors.Proxy, hexTxHash string, status transaction.TxStatus) *transactionFailedError {
+	txErr := &transactionFailedError{
+		hash:    hexTxHash,
+		status:  status,
+		reasons: make([]string, 0),
+	}
+
+	txInfo, err := proxy.(transactionInfoProxy).GetTransactionInfoWithResults(context.Background(), hexTxHash)
+	if err != nil {
+		log.Warn("can not fetch the failed transaction", "tx hash", hexTxHash, "error", err)
+		return txErr
+	}
+
+	tx := txInfo.Data.Transaction
+	txErr.addReasonsFromLogs(tx.Logs)
+	for _, scr := range tx.ScResults {
+		if len(scr.ReturnMessage) > 0 {
+			txErr.addReason(scr.ReturnMessage)
+		}
+		txErr.addReason(decodeReturnCode(scr.Data))
+		txErr.addReasonsFromLogs(scr.Logs)
+	}
+
+	return txErr
+}
+
+func (err *transactionFailedError) addReasonsFromLogs(logs *transaction.ApiLogs) {
+	if logs == nil {
+		return
+	}
+
+	for _, event := range logs.Events {
+		switch event.Identifier {
+		case signalErrorEventIdentifier:
+			// the second topic holds the error message, the data field holds the return code
+			if len(event.Topics) > 1 {
+				err.addReason(string(event.Topics[1]))
+			}
+			err.addReason(decodeReturnCode(string(event.Data)))
+		case internalVMErrorsEventIdentifier:
+			err.addReason(strings.TrimSpace(string(event.Data)))
+		}
+	}
+}
+
+// addReason appends the reason if it is not empty and was not already found
+func (err *transactionFailedError) addReason(reason string) {
+	if len(reason) == 0 {
+		return
+	}
+	for _, existing := range err.reasons {
+		if existing == reason {
+			return
+		}
+	}
+
+	err.reasons = append(err.reasons, reason)
+}
+
+// decodeReturnCode decodes a "@<hex return code>@..." data field, returning an empty string for non-error data
+func decodeReturnCode(dataField string) string {
+	if !strings.HasPrefix(dataField, "@") {
+		return ""
+	}
+
+	hexReturnCode := strings.Split(dataField[1:], "@")[0]
+	if hexReturnCode == okReturnCodeHex {
+		return ""
+	}
+
+	returnCode, err := hex.DecodeString(hexReturnCode)
+	if err != nil || len(returnCode) == 0 {
+		return ""
+	}
+
+	return string(returnCode)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+const failedTxHash = "5f0e3c2a"
+
+var userErrorReturnCode = "@" + hex.EncodeToString([]byte("user error"))
+
+// failedDelegateTransaction is a delegate call rejected by the contract, as the proxy returns it: the contract signals
+// the error, the refund SCR carries the same message and the user error return code
+func failedDelegateTransaction() data.TransactionOnNetwork {
+	return data.TransactionOnNetwork{
+		Hash: failedTxHash,
+		Logs: &transaction.ApiLogs{
+			Events: []*transaction.Events{
+				{Identifier: "writeLog", Topics: [][]byte{[]byte("sender")}, Data: []byte("@" + okReturnCodeHex)},
+				{
+					Identifier: signalErrorEventIdentifier,
+					Topics:     [][]byte{[]byte("sender"), []byte("delegation cap reached")},
+					Data:       []byte(userErrorReturnCode),
+				},
+			},
+		},
+		ScResults: []*transaction.ApiSmartContractResult{
+			{Data: userErrorReturnCode, ReturnMessage: "delegation cap reached"},
+			{
+				Data: "@" + okReturnCodeHex,
+				Logs: &transaction.ApiLogs{
+					Events: []*transaction.Events{
+						{Identifier: internalVMErrorsEventIdentifier, Data: []byte("\n\truntime.go:856 [execution failed] [delegate]\n")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWaitReturnsTheDecodedFailure(t *testing.T) {
+	proxy := &proxyStub{
+		statuses:     map[string]transaction.TxStatus{failedTxHash: transaction.TxStatusFail},
+		transactions: map[string]data.TransactionOnNetwork{failedTxHash: failedDelegateTransaction()},
+	}
+
+	err := waitForTransactionToCompleteSuccessfully(proxy, &data.NetworkConfig{RoundDuration: 1}, failedTxHash)
+
+	var txErr *transactionFailedError
+	if !errors.As(err, &txErr) {
+		t.Fatalf("expected a *transactionFailedError, got %v", err)
+	}
+	expectedReasons := []string{"delegation cap reached", "user error", "runtime.go:856 [execution failed] [delegate]"}
+	if !reflect.DeepEqual(txErr.reasons, expectedReasons) {
+		t.Errorf("expected the reasons %q, got %q", expectedReasons, txErr.reasons)
+	}
+	expectedMessage := "transaction 5f0e3c2a failed with status fail: delegation cap reached; user error; " +
+		"runtime.go:856 [execution failed] [delegate]"
+	if err.Error() != expectedMessage {
+		t.Errorf("expected %q, got %q", expectedMessage, err.Error())
+	}
+}
+
+func TestFailedTransactionThatCanNotBeFetched(t *testing.T) {
+	proxy := &proxyStub{
+		statuses: map[string]transaction.TxStatus{failedTxHash: transaction.TxStatusInvalid},
+	}
+
+	err := waitForTransactionToCompleteSuccessfully(proxy, &data.NetworkConfig{RoundDuration: 1}, failedTxHash)
+
+	var txErr *transactionFailedError
+	if !errors.As(err, &txErr) {
+		t.Fatalf("expected a *transactionFailedError, got %v", err)
+	}
+	if len(txErr.reasons) != 0 {
+		t.Errorf("expected no reasons, got %q", txErr.reasons)
+	}
+	if !strings.HasSuffix(err.Error(), "failed with status invalid, no reason found") {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestDecodeReturnCode(t *testing.T) {
+	for dataField, expected := range map[string]string{
+		"":                            "",
+		"delegate@0a":                 "",
+		"@" + okReturnCodeHex + "@0a": "",
+		"@not-hex":                    "",
+		userErrorReturnCode + "@0a":   "user error",
+		"@6f7574206f6620676173":       "out of gas",
+	} {
+		decoded := decodeReturnCode(dataField)
+		if decoded != expected {
+			t.Errorf("decodeReturnCode(%q) returned %q, expected %q", dataField, decoded, expected)
+		}
+	}
+}
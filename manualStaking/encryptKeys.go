@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path"
+
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/keys"
+)
+
+// runEncryptKeys converts, in every account directory, the wallet.pem into a wallet.json keystore and the all.pem
+// into an all.json encrypted BLS keys container, using the keys password. The sponsor.pem from the working directory
+// is converted as well, if present. The plaintext files are only removed if requested.
+func runEncryptKeys(args []string) {
+	flags := flag.NewFlagSet(commandEncrypt, flag.ExitOnError)
+	dir := flags.String("dir", keysDir, "the directory holding the account directories")
+	removePlaintext := flags.Bool("remove-plaintext", false, "remove the PEM files after encrypting them")
+	_ = flags.Parse(args)
+
+	password, err := keyPasswords.Password("the encrypted key files")
+	requireNilErr(err)
+	if len(password) == 0 {
+		panic("refusing to encrypt the keys with an empty password")
+	}
+
+	entries, err := os.ReadDir(*dir)
+	requireNilErr(err)
+
+	numEncrypted := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dirPath := path.Join(*dir, entry.Name())
+		numEncrypted += encryptWalletKeyFile(path.Join(dirPath, walletKeyFilename), path.Join(dirPath, walletKeystoreFilename), password, *removePlaintext)
+		numEncrypted += encryptBlsKeysFile(path.Join(dirPath, validatorsKeysFilename), path.Join(dirPath, validatorsKeysContainerFilename), password, *removePlaintext)
+	}
+	numEncrypted += encryptWalletKeyFile(sponsorWalletFilename, sponsorKeystoreFilename, password, *removePlaintext)
+
+	log.Info("encrypted key files", "num files", numEncrypted, "plaintext removed", *removePlaintext)
+}
+
+func encryptWalletKeyFile(pemFilename string, keystoreFilename string, password string, removePlaintext bool) int {
+	if !shouldEncryptKeyFile(pemFilename, keystoreFilename) {
+		return 0
+	}
+
+	wallet := interactors.NewWallet()
+	skBytes, err := wallet.LoadPrivateKeyFromPemFile(pemFilename)
+	requireNilErr(err)
+	defer keys.Wipe(skBytes)
+
+	err = wallet.SavePrivateKeyToJsonFile(skBytes, password, keystoreFilename)
+	requireNilErr(err)
+
+	// check the keystore opens with the password before any plaintext is removed
+	decryptedSk, err := wallet.LoadPrivateKeyFromJsonFile(keystoreFilename, password)
+	requireNilErr(err)
+	keys.Wipe(decryptedSk)
+
+	finishKeyFileEncryption(pemFilename, keystoreFilename, removePlaintext)
+
+	return 1
+}
+
+func encryptBlsKeysFile(pemFilename string, containerFilename string, password string, removePlaintext bool) int {
+	if !shouldEncryptKeyFile(pemFilename, containerFilename) {
+		return 0
+	}
+
+	pemData, err := os.ReadFile(pemFilename)
+	requireNilErr(err)
+	defer keys.Wipe(pemData)
+
+	containerData, err := keys.EncryptBlsKeys(pemData, password)
+	requireNilErr(err)
+
+	decryptedPem, err := keys.DecryptBlsKeys(containerData, password)
+	requireNilErr(err)
+	keys.Wipe(decryptedPem)
+
+	err = os.WriteFile(containerFilename, containerData, 0600)
+	requireNilErr(err)
+
+	finishKeyFileEncryption(pemFilename, containerFilename, removePlaintext)
+
+	return 1
+}
+
+// shouldEncryptKeyFile returns true if the PEM file exists and was not already encrypted
+func shouldEncryptKeyFile(pemFilename string, encryptedFilename string) bool {
+	_, err := os.Stat(pemFilename)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	requireNilErr(err)
+
+	_, err = os.Stat(encryptedFilename)
+	if err == nil {
+		log.Warn("encrypted file already exists, skipping", "file", encryptedFilename)
+		return false
+	}
+
+	return true
+}
+
+func finishKeyFileEncryption(pemFilename string, encryptedFilename string, removePlaintext bool) {
+	log.Info("encrypted key file", "source", pemFilename, "destination", encryptedFilename)
+	if !removePlaintext {
+		return
+	}
+
+	err := os.Remove(pemFilename)
+	requireNilErr(err)
+}
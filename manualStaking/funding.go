@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+// accountFunding is the funding breakdown for one account. The account's own fees are minted on top of its stake and
+// buffer, the buffer being kept as a margin over the estimated fees.
+type accountFunding struct {
+	owner         string
+	stake         *big.Int
+	buffer        *big.Int
+	mintFee       *big.Int
+	stakeFees     *big.Int
+	delegationFee *big.Int
+	configFees    *big.Int
+}
+
+// toMint returns the value minted to the account: its stake, the buffer and the fees it pays on its own
+func (af *accountFunding) toMint() *big.Int {
+	total := big.NewInt(0).Add(af.stake, af.buffer)
+	return total.Add(total, af.accountFees())
+}
+
+// fromSponsor returns the value the sponsor spends for this account: the minted value and the mint fee
+func (af *accountFunding) fromSponsor() *big.Int {
+	return big.NewInt(0).Add(af.toMint(), af.mintFee)
+}
+
+// accountFees returns the maximum fees the account pays on its own, for the stake, delegation & config transactions
+func (af *accountFunding) accountFees() *big.Int {
+	total := big.NewInt(0).Add(af.stakeFees, af.delegationFee)
+	return total.Add(total, af.configFees)
+}
+
+// checkSponsorFunding computes, before any transaction is sent, the full amount needed from the sponsor: the stakes,
+// the buffers and the estimated fees of the mints, stake chunks, delegation creation & config calls. It aborts with a
+// per account breakdown if the sponsor balance is too low. The fees are estimated as gas limit x gas price, the maximum
+// the sender has to hold when sending. The returned fundings are keyed by the account address.
+func checkSponsorFunding(
+	readStakeInfo []*stakeInfo,
+	sponsorBalance string,
+	netConfig *data.NetworkConfig,
+	estimator func(si *stakeInfo, netConfig *data.NetworkConfig) *accountFunding,
+) map[string]*accountFunding {
+	balance, ok := big.NewInt(0).SetString(sponsorBalance, 10)
+	if !ok {
+		panic(fmt.Sprintf("invalid sponsor balance %s", sponsorBalance))
+	}
+
+	fundings := make(map[string]*accountFunding, len(readStakeInfo))
+	required := big.NewInt(0)
+	for _, si := range readStakeInfo {
+		funding := estimator(si, netConfig)
+		fundings[funding.owner] = funding
+		required.Add(required, funding.fromSponsor())
+	}
+
+	if balance.Cmp(required) >= 0 {
+		log.Info("sponsor funding check passed", "balance", balance.String(), "required", required.String())
+		return fundings
+	}
+
+	// the accounts are funded in order, so the balance covers the first accounts in full and the rest fall short
+	remaining := big.NewInt(0).Set(balance)
+	for _, si := range readStakeInfo {
+		funding := fundings[si.walletKey.bech32Address]
+		covered, missing := coverFunding(remaining, funding.fromSponsor())
+		remaining.Sub(remaining, covered)
+		log.Warn("account funding",
+			"owner", funding.owner,
+			"stake", funding.stake.String(),
+			"buffer", funding.buffer.String(),
+			"mint fee", funding.mintFee.String(),
+			"stake fees", funding.stakeFees.String(),
+			"delegation fee", funding.delegationFee.String(),
+			"config fees", funding.configFees.String(),
+			"to mint", funding.toMint().String(),
+			"from sponsor", funding.fromSponsor().String(),
+			"covered", covered.String(),
+			"missing", missing.String())
+	}
+
+	shortfall := big.NewInt(0).Sub(required, balance)
+	if shortfall.Sign() < 0 {
+		shortfall.SetInt64(0)
+	}
+	log.Error("insufficient funding",
+		"sponsor balance", balance.String(),
+		"required", required.String(),
+		"shortfall", shortfall.String())
+
+	panic("insufficient funding")
+}
+
+// coverFunding splits the required value into the part the available balance covers and the missing part
+func coverFunding(available *big.Int, required *big.Int) (*big.Int, *big.Int) {
+	covered := big.NewInt(0).Set(required)
+	if available.Cmp(required) < 0 {
+		covered.Set(available)
+	}
+	if covered.Sign() < 0 {
+		covered.SetInt64(0)
+	}
+
+	return covered, big.NewInt(0).Sub(required, covered)
+}
+
+// estimateAccountFunding estimates the funding for the stake command: direct stake followed by the conversion of the
+// validator into a delegation contract
+func estimateAccountFunding(si *stakeInfo, netConfig *data.NetworkConfig) *accountFunding {
+	gasPrice := big.NewInt(0).SetUint64(netConfig.MinGasPrice)
+
+	stakeGas := uint64(0)
+	numKeysInTx := 0
+	for blsIndex := range si.blsPublicKeys {
+		numKeysInTx++
+		if shouldCloseStakeTx(blsIndex, len(si.blsPublicKeys)) {
+			stakeGas += baseStakeGas + uint64(numKeysInTx)*stakeGasPerNode
+			numKeysInTx = 0
+		}
+	}
+	if numKeysInTx > 0 {
+		stakeGas += baseStakeGas + uint64(numKeysInTx)*stakeGasPerNode
+	}
+
+	configGas := uint64(0)
+	for _, call := range delegationConfigCalls(si.manifest) {
+		configGas += delegationOwnerCallGas + uint64(dataByteGasLimit*len(call))
+	}
+
+	return &accountFunding{
+		owner:         si.walletKey.bech32Address,
+		stake:         si.stakeValue,
+		buffer:        oneELGD,
+		mintFee:       gasFee(mintGasLimit+uint64(dataByteGasLimit*len(mintData)), gasPrice),
+		stakeFees:     gasFee(stakeGas, gasPrice),
+		delegationFee: gasFee(makeContractGas, gasPrice),
+		configFees:    gasFee(configGas, gasPrice),
+	}
+}
+
+func gasFee(gasLimit uint64, gasPrice *big.Int) *big.Int {
+	return big.NewInt(0).Mul(big.NewInt(0).SetUint64(gasLimit), gasPrice)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCoverFundingInAccountOrder(t *testing.T) {
+	// a balance of 250 for three accounts needing 100 each: the first two are covered, the third misses 50
+	remaining := big.NewInt(250)
+	expectedMissing := []int64{0, 0, 50}
+	for i, expected := range expectedMissing {
+		covered, missing := coverFunding(remaining, big.NewInt(100))
+		remaining.Sub(remaining, covered)
+
+		if missing.Int64() != expected {
+			t.Errorf("account %d: missing %s, expected %d", i, missing.String(), expected)
+		}
+		if big.NewInt(0).Add(covered, missing).Int64() != 100 {
+			t.Errorf("account %d: covered %s and missing %s do not add up to the requirement", i, covered.String(), missing.String())
+		}
+	}
+
+	covered, missing := coverFunding(remaining, big.NewInt(30))
+	if covered.Sign() != 0 || missing.Int64() != 30 {
+		t.Errorf("an exhausted balance should cover nothing, got covered %s, missing %s", covered.String(), missing.String())
+	}
+	if remaining.Sign() != 0 {
+		t.Errorf("the balance should be exhausted, %s left", remaining.String())
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+
+	"github.com/multiversx/mx-chain-core-go/core"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+	"v1/queries"
+)
+
+const indexFilename = "index.json"
+
+// generatedAccount is the summary index entry written for each generated account directory
+type generatedAccount struct {
+	Directory     string   `json:"directory"`
+	Address       string   `json:"address"`
+	StakeValue    string   `json:"stakeValue"`
+	BlsPublicKeys []string `json:"blsPublicKeys"`
+}
+
+// runGenerate creates the keys directory layout expected by the stake command: one directory for each account
+// holding a wallet.pem, an all.pem with the BLS keys and a manifest.json
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet(commandGenerate, flag.ExitOnError)
+	outputDir := flags.String("dir", keysDir, "the directory in which the account directories are created")
+	numAccounts := flags.Int("accounts", 1, "the number of accounts to generate")
+	numKeys := flags.Int("keys", 1, "the number of BLS keys to generate for each account")
+	stake := flags.Int64("stake", 0, "the stake value in EGLD for each account, defaults to keys x the node price read from the chain")
+	name := flags.String("name", "", "the delegation contract name written in each manifest, suffixed with the account index")
+	website := flags.String("website", "", "the delegation contract website written in each manifest")
+	identity := flags.String("identity", "", "the delegation contract identity written in each manifest")
+	_ = flags.Parse(args)
+
+	if *numAccounts <= 0 || *numKeys <= 0 {
+		panic("the number of accounts and the number of keys must be positive")
+	}
+	stakeEGLD := *stake
+	if stakeEGLD == 0 {
+		stakeEGLD = int64(*numKeys) * readNodePriceEGLD()
+	}
+
+	err := os.MkdirAll(*outputDir, 0755)
+	requireNilErr(err)
+
+	index := make([]*generatedAccount, 0, *numAccounts)
+	for i := 0; i < *numAccounts; i++ {
+		// the stake value must be the 5th space separated token, as parseStakeDir expects
+		dirName := fmt.Sprintf("account %03d %d keys %d EGLD", i, *numKeys, stakeEGLD)
+		dirPath := path.Join(*outputDir, dirName)
+		err = os.Mkdir(dirPath, 0700)
+		requireNilErr(err)
+
+		manifest := &accountManifest{
+			Website:  *website,
+			Identity: *identity,
+		}
+		if len(*name) > 0 {
+			manifest.Name = fmt.Sprintf("%s %03d", *name, i)
+		}
+
+		account := &generatedAccount{
+			Directory:     dirName,
+			Address:       generateWalletPem(path.Join(dirPath, walletKeyFilename)),
+			StakeValue:    fmt.Sprintf("%d", stakeEGLD),
+			BlsPublicKeys: generateBlsPem(path.Join(dirPath, validatorsKeysFilename), *numKeys),
+		}
+		writeJSONFile(path.Join(dirPath, manifestFilename), manifest)
+		index = append(index, account)
+
+		log.Info("generated account", "directory", dirPath, "address", account.Address, "num BLS keys", *numKeys)
+	}
+
+	writeJSONFile(path.Join(*outputDir, indexFilename), index)
+	log.Info("generated accounts", "num accounts", *numAccounts, "index", path.Join(*outputDir, indexFilename))
+}
+
+// readNodePriceEGLD returns the current node price in whole EGLD, as the stake value is written in the directory name
+func readNodePriceEGLD() int64 {
+	nodePrice, err := queries.GetNodePrice(createTestnetProxy().(queries.EpochStartProxy))
+	requireNilErr(err)
+
+	nodePriceEGLD, remainder := big.NewInt(0).QuoRem(nodePrice, oneELGD, big.NewInt(0))
+	if remainder.Sign() != 0 {
+		panic(fmt.Sprintf("the node price %s is not a whole EGLD value, provide the stake with -stake", nodePrice.String()))
+	}
+
+	return nodePriceEGLD.Int64()
+}
+
+func generateWalletPem(filename string) string {
+	sk, _ := walletKeyGen.GeneratePair()
+	skBytes, err := sk.ToByteArray()
+	requireNilErr(err)
+
+	wallet := interactors.NewWallet()
+	err = wallet.SavePrivateKeyToPemFile(skBytes, filename)
+	requireNilErr(err)
+
+	address, err := wallet.GetAddressFromPrivateKey(skBytes)
+	requireNilErr(err)
+
+	bech32Address, err := address.AddressAsBech32String()
+	requireNilErr(err)
+
+	return bech32Address
+}
+
+// generateBlsPem writes the keys in the multi-key format read by core.LoadAllKeysFromPemFile
+func generateBlsPem(filename string, numKeys int) []string {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	requireNilErr(err)
+	defer func() {
+		_ = file.Close()
+	}()
+
+	publicKeys := make([]string, 0, numKeys)
+	for i := 0; i < numKeys; i++ {
+		sk, pk := blsKeyGen.GeneratePair()
+		skBytes, errConvert := sk.ToByteArray()
+		requireNilErr(errConvert)
+
+		pkBytes, errConvert := pk.ToByteArray()
+		requireNilErr(errConvert)
+
+		pkHex := hex.EncodeToString(pkBytes)
+		err = core.SaveSkToPemFile(file, pkHex, []byte(hex.EncodeToString(skBytes)))
+		requireNilErr(err)
+
+		publicKeys = append(publicKeys, pkHex)
+	}
+
+	return publicKeys
+}
+
+func writeJSONFile(filename string, value interface{}) {
+	err := common.SaveJSONFile(filename, value)
+	requireNilErr(err)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+	"v1/queries"
+)
+
+const validatorCallGas = 6000000
+
+// keysOperation describes a validator SC call done with a list of the account's BLS keys
+type keysOperation struct {
+	function string
+	// isEligible returns true if the key, having the provided status, can be included in the call
+	isEligible func(proxy interactors.Proxy, si *stakeInfo, blsKey string, status string) bool
+	// isDone returns true if the status read after the call is the expected one
+	isDone      func(status string) bool
+	valuePerKey *big.Int
+}
+
+func runUnStake(args []string) {
+	runKeysOperation(commandUnStake, args, &keysOperation{
+		function: "unStakeNodes",
+		isEligible: func(_ interactors.Proxy, _ *stakeInfo, _ string, status string) bool {
+			return status == queries.StakingStatusStaked || status == queries.StakingStatusQueued
+		},
+		isDone: func(status string) bool {
+			return status == queries.StakingStatusUnStaked
+		},
+		valuePerKey: big.NewInt(0),
+	})
+}
+
+func runUnBond(args []string) {
+	runKeysOperation(commandUnBond, args, &keysOperation{
+		function: "unBondNodes",
+		isEligible: func(proxy interactors.Proxy, si *stakeInfo, blsKey string, status string) bool {
+			if status != queries.StakingStatusUnStaked {
+				return false
+			}
+
+			remaining, err := queries.GetRemainingUnBondPeriod(proxy.(queries.VMQueryProxy), si.walletKey.bech32Address, decodeHexKey(blsKey))
+			if err != nil {
+				log.Warn("can not read the remaining unbond period", "key", blsKey, "error", err)
+				return false
+			}
+			if remaining > 0 {
+				log.Info("key is still in the unbond period", "key", blsKey, "remaining rounds", remaining)
+				return false
+			}
+
+			return true
+		},
+		isDone: func(status string) bool {
+			return status == queries.StakingStatusNotRegistered
+		},
+		valuePerKey: big.NewInt(0),
+	})
+}
+
+func runReStake(args []string) {
+	runKeysOperation(commandReStake, args, &keysOperation{
+		function: "reStakeUnStakedNodes",
+		isEligible: func(_ interactors.Proxy, _ *stakeInfo, _ string, status string) bool {
+			return status == queries.StakingStatusUnStaked
+		},
+		isDone: func(status string) bool {
+			return status == queries.StakingStatusStaked || status == queries.StakingStatusQueued
+		},
+		valuePerKey: big.NewInt(0),
+	})
+}
+
+func runUnJail(args []string) {
+	flags := flag.NewFlagSet(commandUnJail, flag.ExitOnError)
+	unJailPriceString := flags.String("unjail-price", "", "the unjail price per key, denominated, as set in the UnJailValue of the network's system SC config")
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	if len(*unJailPriceString) == 0 {
+		panic("the unjail price is not exposed by the validator SC, provide it with -unjail-price")
+	}
+	unJailPrice, ok := big.NewInt(0).SetString(*unJailPriceString, 10)
+	if !ok || unJailPrice.Sign() <= 0 {
+		panic(fmt.Sprintf("invalid unjail price %s", *unJailPriceString))
+	}
+
+	processKeysOperationInParallel(*numWorkers, &keysOperation{
+		function: "unJail",
+		isEligible: func(_ interactors.Proxy, _ *stakeInfo, _ string, status string) bool {
+			return status == queries.StakingStatusJailed
+		},
+		isDone: func(status string) bool {
+			return status != queries.StakingStatusJailed
+		},
+		valuePerKey: unJailPrice,
+	})
+}
+
+func runKeysOperation(command string, args []string, operation *keysOperation) {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	processKeysOperationInParallel(*numWorkers, operation)
+}
+
+// processKeysOperationInParallel reads the status of every key in the stakeInfo directory, sends the operation for the
+// eligible keys of each account and checks the keys reached the expected status afterwards
+func processKeysOperationInParallel(numWorkers int, operation *keysOperation) {
+	readStakeInfo := readDirStakeInfo()
+	proxy := createTestnetProxy()
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	failed := processStakeInfosInParallel(readStakeInfo, numWorkers, func(si *stakeInfo) {
+		processKeysOperation(si, proxy, netConfigs, operation)
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("%s failed for %d account(s): %s", operation.function, len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+func processKeysOperation(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig, operation *keysOperation) {
+	log.Info("")
+	log.Info("############### " + operation.function + " for " + si.walletKey.bech32Address + " ###############")
+
+	eligibleKeys := make([]string, 0, len(si.blsPublicKeys))
+	for _, blsKey := range si.blsPublicKeys {
+		status := getBlsKeyStatus(proxy, si.walletKey.bech32Address, blsKey)
+		if !operation.isEligible(proxy, si, blsKey, status) {
+			log.Info("skipping key", "function", operation.function, "key", blsKey, "status", status)
+			continue
+		}
+
+		eligibleKeys = append(eligibleKeys, blsKey)
+	}
+
+	if len(eligibleKeys) == 0 {
+		log.Info("no eligible keys", "function", operation.function, "owner", si.walletKey.bech32Address)
+		return
+	}
+
+	validatorAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	for start := 0; start < len(eligibleKeys); start += maxKeysInNodesTx {
+		end := start + maxKeysInNodesTx
+		if end > len(eligibleKeys) {
+			end = len(eligibleKeys)
+		}
+
+		value := big.NewInt(0).Mul(operation.valuePerKey, big.NewInt(int64(end-start)))
+		txData := operation.function + "@" + strings.Join(eligibleKeys[start:end], "@")
+		sendTransactionAndWait(proxy, si.walletKey, netConfig, validatorAddress, value, nodesTxGasLimit(end-start), txData)
+	}
+
+	numNotDone := 0
+	for _, blsKey := range eligibleKeys {
+		status := getBlsKeyStatus(proxy, si.walletKey.bech32Address, blsKey)
+		if operation.isDone(status) {
+			continue
+		}
+
+		log.Error("key did not reach the expected status", "function", operation.function, "key", blsKey, "status", status)
+		numNotDone++
+	}
+	if numNotDone > 0 {
+		panic(fmt.Sprintf("%d key(s) did not reach the expected status after %s", numNotDone, operation.function))
+	}
+
+	log.Info("operation done", "function", operation.function, "owner", si.walletKey.bech32Address, "num keys", len(eligibleKeys))
+}
+
+// getBlsKeyStatus returns the staking SC status of the key
+func getBlsKeyStatus(proxy interactors.Proxy, caller string, blsKey string) string {
+	status, err := queries.GetBLSKeyStatus(proxy.(queries.VMQueryProxy), caller, decodeHexKey(blsKey))
+	requireNilErr(err)
+
+	return status
+}
+
+func decodeHexKey(hexKey string) []byte {
+	key, err := hex.DecodeString(hexKey)
+	requireNilErr(err)
+
+	return key
+}
+
+func runUnStakeTokens(args []string) {
+	flags := flag.NewFlagSet(commandUnStakeTokens, flag.ExitOnError)
+	valueString := flags.String("value", "", "the top-up value to unstake from each account, in EGLD")
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	value, err := common.ParseEGLDValue(*valueString)
+	requireNilErr(err)
+	if value.Sign() <= 0 {
+		panic("the value to unstake must be positive")
+	}
+
+	readStakeInfo := readDirStakeInfo()
+	proxy := createTestnetProxy()
+	cfg := readStakingConfig(proxy)
+	if value.Cmp(cfg.minDelegationAmount) < 0 {
+		panic(fmt.Sprintf("the value to unstake is below the minimum of %s", cfg.minDelegationAmount.String()))
+	}
+
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	validatorAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	failed := processStakeInfosInParallel(readStakeInfo, *numWorkers, func(si *stakeInfo) {
+		stakeData, errGet := getValidatorStakeData(proxy, si.walletKey.bech32Address)
+		requireNilErr(errGet)
+		if stakeData.topUp.Cmp(value) < 0 {
+			panic(fmt.Sprintf("top-up of %s is %s, can not unstake %s", si.walletKey.bech32Address, stakeData.topUp.String(), value.String()))
+		}
+
+		unStakedBefore := getUnStakedTokens(proxy, si.walletKey.bech32Address)
+		txData := "unStakeTokens@" + common.HexBigInt(value)
+		sendTransactionAndWait(proxy, si.walletKey, netConfigs, validatorAddress, big.NewInt(0), validatorCallGas, txData)
+
+		unStakedAfter := getUnStakedTokens(proxy, si.walletKey.bech32Address)
+		expected := big.NewInt(0).Add(unStakedBefore.total, value)
+		if unStakedAfter.total.Cmp(expected) != 0 {
+			panic(fmt.Sprintf("unstaked tokens of %s are %s, expected %s", si.walletKey.bech32Address, unStakedAfter.total.String(), expected.String()))
+		}
+
+		log.Info("unstaked tokens", "owner", si.walletKey.bech32Address, "value", value.String(), "total unstaked", unStakedAfter.total.String())
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("unStakeTokens failed for %d account(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+func runUnBondTokens(args []string) {
+	flags := flag.NewFlagSet(commandUnBondTokens, flag.ExitOnError)
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	readStakeInfo := readDirStakeInfo()
+	proxy := createTestnetProxy()
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	validatorAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	failed := processStakeInfosInParallel(readStakeInfo, *numWorkers, func(si *stakeInfo) {
+		unStakedBefore := getUnStakedTokens(proxy, si.walletKey.bech32Address)
+		if unStakedBefore.unBondable.Sign() == 0 {
+			log.Info("nothing to unbond", "owner", si.walletKey.bech32Address, "total unstaked", unStakedBefore.total.String())
+			return
+		}
+
+		sendTransactionAndWait(proxy, si.walletKey, netConfigs, validatorAddress, big.NewInt(0), validatorCallGas, "unBondTokens")
+
+		unStakedAfter := getUnStakedTokens(proxy, si.walletKey.bech32Address)
+		if unStakedAfter.unBondable.Sign() != 0 {
+			panic(fmt.Sprintf("%s still has %s unbondable tokens", si.walletKey.bech32Address, unStakedAfter.unBondable.String()))
+		}
+
+		log.Info("unbonded tokens", "owner", si.walletKey.bech32Address, "value", unStakedBefore.unBondable.String())
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("unBondTokens failed for %d account(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+// unStakedTokens sums the validator SC getUnStakedTokensList entries, unBondable being the part with no epochs left
+type unStakedTokens struct {
+	total      *big.Int
+	unBondable *big.Int
+}
+
+func getUnStakedTokens(proxy interactors.Proxy, owner string) *unStakedTokens {
+	validatorAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	returnData, err := executeVMQuery(proxy, validatorAddress, owner, "getUnStakedTokensList", decodeBech32Address(owner))
+	requireNilErr(err)
+
+	tokens := &unStakedTokens{
+		total:      big.NewInt(0),
+		unBondable: big.NewInt(0),
+	}
+	// the list holds value, remaining epochs pairs
+	for i := 0; i+1 < len(returnData); i += 2 {
+		value := big.NewInt(0).SetBytes(returnData[i])
+		tokens.total.Add(tokens.total, value)
+		if big.NewInt(0).SetBytes(returnData[i+1]).Sign() == 0 {
+			tokens.unBondable.Add(tokens.unBondable, value)
+		}
+	}
+
+	return tokens
+}
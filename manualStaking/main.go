@@ -2,15 +2,14 @@ package main
 
 import (
 	"context"
-	"encoding/hex"
+	"flag"
 	"fmt"
 	"math/big"
 	"os"
 	"path"
 	"strings"
-	"time"
+	"sync"
 
-	"github.com/multiversx/mx-chain-core-go/core"
 	"github.com/multiversx/mx-chain-core-go/data/transaction"
 	"github.com/multiversx/mx-chain-crypto-go/signing"
 	"github.com/multiversx/mx-chain-crypto-go/signing/ed25519"
@@ -18,7 +17,6 @@ import (
 	"github.com/multiversx/mx-chain-crypto-go/signing/mcl/singlesig"
 	"github.com/multiversx/mx-chain-go/vm"
 	logger "github.com/multiversx/mx-chain-logger-go"
-	"github.com/multiversx/mx-sdk-go/blockchain"
 	"github.com/multiversx/mx-sdk-go/blockchain/cryptoProvider"
 	"github.com/multiversx/mx-sdk-go/builders"
 	sdkCore "github.com/multiversx/mx-sdk-go/core"
@@ -26,36 +24,40 @@ import (
 	"github.com/multiversx/mx-sdk-go/examples"
 	"github.com/multiversx/mx-sdk-go/interactors"
 	"github.com/multiversx/mx-sdk-go/workflows"
+	"v1/common"
+	"v1/keys"
 )
 
 const keysDir = `/home/jules01/keys`
 const idxEGLD = 4
 const walletKeyFilename = "wallet.pem"
+const walletKeystoreFilename = "wallet.json"
 const validatorsKeysFilename = "all.pem"
+const validatorsKeysContainerFilename = "all.json"
 const sponsorWalletFilename = "sponsor.pem"
+const sponsorKeystoreFilename = "sponsor.json"
 const gateway = examples.TestnetGateway // for local testnet, use "http://127.0.0.1:7950"
 const dataByteGasLimit = 1500
-const maxTimeoutForTransactionToComplete = time.Minute * 2
-const blockTime = time.Second * 6
 const stakeGasPerNode = 6000000
 const baseStakeGas = 50000000
 const makeContractGas = 510000000
 const feeString = "0320" // 8.00%
 
 var oneELGD = big.NewInt(1000000000000000000)
-var stakeForOneNode = big.NewInt(0).Mul(big.NewInt(2500), oneELGD)
 var log = logger.GetOrCreate("manualStaking")
 var walletSuite = ed25519.NewEd25519()
 var blsSuite = mcl.NewSuiteBLS12()
 var walletKeyGen = signing.NewKeyGenerator(walletSuite)
 var blsKeyGen = signing.NewKeyGenerator(blsSuite)
 var blsSingleSigner = singlesig.NewBlsSigner()
+var keyPasswords = keys.NewPasswordProvider("", nil)
 
 type stakeInfo struct {
 	walletKey      *walletKeyAddress
 	blsPrivateKeys [][]byte
 	blsPublicKeys  []string
 	stakeValue     *big.Int
+	manifest       *accountManifest
 }
 
 type walletKeyAddress struct {
@@ -68,7 +70,79 @@ type processStatusProxy interface {
 	ProcessTransactionStatus(ctx context.Context, hexTxHash string) (transaction.TxStatus, error)
 }
 
+const (
+	commandStake    = "stake"
+	commandGenerate = "generate"
+	commandAddNodes = "add-nodes"
+	commandCreate   = "create-delegation"
+	commandMerge    = "merge"
+	commandEncrypt  = "encrypt-keys"
+	commandRewards  = "change-reward-address"
+
+	commandUnStake       = "unstake"
+	commandUnBond        = "unbond"
+	commandReStake       = "restake"
+	commandUnJail        = "unjail"
+	commandUnStakeTokens = "unstake-tokens"
+	commandUnBondTokens  = "unbond-tokens"
+)
+
+var availableCommands = []string{commandStake, commandGenerate, commandAddNodes, commandCreate, commandMerge, commandEncrypt, commandRewards,
+	commandUnStake, commandUnBond, commandReStake, commandUnJail, commandUnStakeTokens, commandUnBondTokens}
+
 func main() {
+	flag.BoolVar(&waitForMetaNotarization, "wait-notarized", false, "count transactions as successful only after the metachain notarized them")
+	passwordFile := flag.String("password-file", "", "the file holding the password shared by the keystore & encrypted BLS keys files, "+
+		"defaults to the "+keys.PasswordEnvVariable+" variable or an interactive prompt")
+	passwordFiles := keys.PasswordFiles{}
+	flag.Var(passwordFiles, "file-password", keys.PasswordFilesUsage)
+	flag.Parse()
+	keyPasswords = keys.NewPasswordProvider(*passwordFile, passwordFiles)
+
+	command := commandStake
+	args := flag.Args()
+	if len(args) > 0 {
+		command = args[0]
+		args = args[1:]
+	}
+
+	switch command {
+	case commandStake:
+		runStake(args)
+	case commandGenerate:
+		runGenerate(args)
+	case commandAddNodes:
+		runAddNodes(args)
+	case commandCreate:
+		runCreateDelegation(args)
+	case commandMerge:
+		runMerge(args)
+	case commandEncrypt:
+		runEncryptKeys(args)
+	case commandRewards:
+		runChangeRewardAddress(args)
+	case commandUnStake:
+		runUnStake(args)
+	case commandUnBond:
+		runUnBond(args)
+	case commandReStake:
+		runReStake(args)
+	case commandUnJail:
+		runUnJail(args)
+	case commandUnStakeTokens:
+		runUnStakeTokens(args)
+	case commandUnBondTokens:
+		runUnBondTokens(args)
+	default:
+		panic(fmt.Sprintf("unknown command %s, available commands: %s", command, strings.Join(availableCommands, ", ")))
+	}
+}
+
+func runStake(args []string) {
+	flags := flag.NewFlagSet(commandStake, flag.ExitOnError)
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
 	readStakeInfo := readDirStakeInfo()
 
 	sum := big.NewInt(0)
@@ -76,9 +150,13 @@ func main() {
 		sum.Add(sum, si.stakeValue)
 	}
 	log.Info("read stake info", "num accounts", len(readStakeInfo), "total sum", sum.String())
+	verifyBlsKeys(readStakeInfo)
 
 	proxy := createTestnetProxy()
-	sponsorWalletKeyAddress := loadWalletKeyAddress(sponsorWalletFilename)
+	cfg := readStakingConfig(proxy)
+	checkStakeValues(readStakeInfo, cfg)
+
+	sponsorWalletKeyAddress := loadWalletKeyAddress(resolveKeyFile("", sponsorWalletFilename, sponsorKeystoreFilename))
 	account, err := proxy.GetAccount(context.Background(), sponsorWalletKeyAddress.address)
 	requireNilErr(err)
 
@@ -87,14 +165,78 @@ func main() {
 	netConfigs, err := proxy.GetNetworkConfig(context.Background())
 	requireNilErr(err)
 
+	fundings := checkSponsorFunding(readStakeInfo, account.Balance, netConfigs, estimateAccountFunding)
+
+	sponsorNonces := newSponsorNonceManager(proxy, sponsorWalletKeyAddress)
+	mints := processMints(readStakeInfo, proxy, sponsorWalletKeyAddress, sponsorNonces, fundings, netConfigs)
+	sponsorWalletKeyAddress.wipe()
+	failed := processStakeInfosInParallel(readStakeInfo, *numWorkers, func(si *stakeInfo) {
+		processStakeInfo(si, proxy, mints[si.walletKey.bech32Address], netConfigs, cfg)
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("processing failed for %d account(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+// processStakeInfosInParallel runs the handler for each account on a pool of workers and returns the addresses of
+// the accounts for which the handler panicked
+func processStakeInfosInParallel(readStakeInfo []*stakeInfo, numWorkers int, handler func(si *stakeInfo)) []string {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	var mutFailed sync.Mutex
+	failed := make([]string, 0)
+	chStakeInfo := make(chan *stakeInfo)
+	wg := &sync.WaitGroup{}
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for si := range chStakeInfo {
+				err := runWithRecover(func() {
+					handler(si)
+				})
+				si.wipeKeys()
+				if err == nil {
+					continue
+				}
+
+				log.Error("account processing failed", "owner", si.walletKey.bech32Address, "error", err)
+				mutFailed.Lock()
+				failed = append(failed, si.walletKey.bech32Address)
+				mutFailed.Unlock()
+			}
+		}()
+	}
+
 	for _, si := range readStakeInfo {
-		processStakeInfo(si, proxy, sponsorWalletKeyAddress, netConfigs)
+		chStakeInfo <- si
 	}
+	close(chStakeInfo)
+	wg.Wait()
+
+	return failed
+}
+
+func runWithRecover(handler func()) (err error) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	handler()
+
+	return nil
 }
 
+// loadWalletKeyAddress loads the wallet key from a PEM or a JSON keystore file
 func loadWalletKeyAddress(filename string) *walletKeyAddress {
 	wallet := interactors.NewWallet()
-	skBytes, err := wallet.LoadPrivateKeyFromPemFile(filename)
+	skBytes, err := keys.LoadWalletKey(filename, keyPasswords)
 	requireNilErr(err)
 
 	address, err := wallet.GetAddressFromPrivateKey(skBytes)
@@ -110,6 +252,30 @@ func loadWalletKeyAddress(filename string) *walletKeyAddress {
 	}
 }
 
+// resolveKeyFile returns the first of the provided files that exists in the directory, defaulting to the first one
+func resolveKeyFile(dirPath string, filenames ...string) string {
+	for _, filename := range filenames {
+		filePath := path.Join(dirPath, filename)
+		_, err := os.Stat(filePath)
+		if err == nil {
+			return filePath
+		}
+	}
+
+	return path.Join(dirPath, filenames[0])
+}
+
+// wipe overwrites the wallet private key, the key can not be used afterwards
+func (wk *walletKeyAddress) wipe() {
+	keys.Wipe(wk.skBytes)
+}
+
+// wipeKeys overwrites the account's wallet & BLS private keys once the account was processed
+func (si *stakeInfo) wipeKeys() {
+	si.walletKey.wipe()
+	keys.WipeAll(si.blsPrivateKeys)
+}
+
 func readDirStakeInfo() []*stakeInfo {
 	entries, err := os.ReadDir(keysDir)
 	requireNilErr(err)
@@ -141,9 +307,9 @@ func parseStakeDir(dirName string) *stakeInfo {
 	val, _ := big.NewInt(0).SetString(egldString, 10)
 	val.Mul(val, oneELGD)
 
-	walletKey := loadWalletKeyAddress(path.Join(dirPath, walletKeyFilename))
+	walletKey := loadWalletKeyAddress(resolveKeyFile(dirPath, walletKeyFilename, walletKeystoreFilename))
 
-	privateKeysBytes, publicKeys, err := core.LoadAllKeysFromPemFile(path.Join(dirPath, validatorsKeysFilename))
+	privateKeysBytes, publicKeys, err := keys.LoadBlsKeys(resolveKeyFile(dirPath, validatorsKeysFilename, validatorsKeysContainerFilename), keyPasswords)
 	requireNilErr(err)
 
 	log.Info("loaded data", "path", dirPath, "value", val.String(), "num BLS keys", len(publicKeys))
@@ -153,59 +319,27 @@ func parseStakeDir(dirName string) *stakeInfo {
 		blsPrivateKeys: privateKeysBytes,
 		blsPublicKeys:  publicKeys,
 		stakeValue:     val,
+		manifest:       loadManifest(dirPath),
 	}
 }
 
-func processStakeInfo(si *stakeInfo, proxy interactors.Proxy, sponsorWallet *walletKeyAddress, netConfig *data.NetworkConfig) {
+func processStakeInfo(si *stakeInfo, proxy interactors.Proxy, mint *pendingMint, netConfig *data.NetworkConfig, cfg *stakingConfig) {
 	log.Info("")
 	log.Info("############### processing for " + si.walletKey.bech32Address + " ###############")
-	processMint(si, proxy, sponsorWallet, netConfig)
-	processStake(si, proxy, netConfig)
-	makeDelegationContract(si, proxy, netConfig)
-}
-
-func processMint(si *stakeInfo, proxy interactors.Proxy, sponsorWallet *walletKeyAddress, netConfig *data.NetworkConfig) {
-	valueToMint := big.NewInt(0).Add(si.stakeValue, oneELGD)
-	log.Info("minting account", "from", sponsorWallet.bech32Address, "to", si.walletKey.bech32Address, "value", valueToMint.String())
-	holder, _ := cryptoProvider.NewCryptoComponentsHolder(walletKeyGen, sponsorWallet.skBytes)
-	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
-	requireNilErr(err)
-
-	ti, err := interactors.NewTransactionInteractor(proxy, txBuilder)
-	requireNilErr(err)
-
-	account, err := proxy.GetAccount(context.Background(), sponsorWallet.address)
-	requireNilErr(err)
-
-	proxyHandler := proxy.(workflows.ProxyHandler)
-	tx, _, err := proxyHandler.GetDefaultTransactionArguments(context.Background(), sponsorWallet.address, netConfig)
-	requireNilErr(err)
-
-	tx.Receiver = si.walletKey.bech32Address
-	tx.Value = valueToMint.String()
-	tx.GasLimit = 50000
-	tx.Data = []byte("initial mint")
-	tx.GasLimit += uint64(dataByteGasLimit * len(tx.Data))
-	tx.Nonce = account.Nonce
-
-	err = ti.ApplyUserSignature(holder, &tx)
+	err := mint.wait()
 	requireNilErr(err)
+	log.Info("mint confirmed", "owner", si.walletKey.bech32Address, "hash", mint.hash)
 
-	ti.AddTransaction(&tx)
-	hash, err := ti.SendTransactionsAsBunch(context.Background(), 1)
+	processStake(si, proxy, netConfig, cfg)
+	hash := makeDelegationContract(si, proxy, netConfig)
+	contractAddress, err := extractDelegationContractAddress(proxy, hash, si.walletKey)
 	requireNilErr(err)
-
-	log.Info("generated & sent tx",
-		"hash", hash[0],
-		"nonce", tx.Nonce,
-		"sender", tx.Sender,
-		"receiver", tx.Receiver,
-		"data", string(tx.Data))
-
-	waitForTransactionToCompleteSuccessfully(proxy, hash[0])
+	log.Info("found delegation contract", "owner", si.walletKey.bech32Address, "contract", contractAddress)
+	recordDelegationContract(si.walletKey.bech32Address, contractAddress)
+	configureDelegationContract(si, si.manifest, contractAddress, proxy, netConfig)
 }
 
-func processStake(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig) {
+func processStake(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig, cfg *stakingConfig) {
 	log.Info("stake keys", "owner", si.walletKey.bech32Address, "num keys", len(si.blsPublicKeys), "stake value", si.stakeValue.String())
 	holder, _ := cryptoProvider.NewCryptoComponentsHolder(walletKeyGen, si.walletKey.skBytes)
 	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
@@ -237,21 +371,15 @@ func processStake(si *stakeInfo, proxy interactors.Proxy, netConfig *data.Networ
 			currentTx = &tx
 		}
 
-		decodedSk, errDecode := hex.DecodeString(string(si.blsPrivateKeys[blsIndex]))
-		requireNilErr(errDecode)
-
-		blsKey, errConvert := blsKeyGen.PrivateKeyFromByteArray(decodedSk)
-		requireNilErr(errConvert)
-
-		hexSig, errSig := blsSingleSigner.Sign(blsKey, si.walletKey.address.AddressBytes())
+		hexSig, errSig := keys.SignWithBlsKey(si.blsPrivateKeys[blsIndex], si.walletKey.address.AddressBytes())
 		requireNilErr(errSig)
 
 		currentTx.Data = append(currentTx.Data, []byte(fmt.Sprintf("@%s@%x", si.blsPublicKeys[blsIndex], hexSig))...)
 		currentTx.GasLimit += stakeGasPerNode
 		numStake++
 
-		if blsIndex%50 == 0 && blsIndex+1 < len(si.blsPublicKeys) && blsIndex > 0 {
-			stakeValue := big.NewInt(0).Mul(big.NewInt(int64(numStake)), stakeForOneNode)
+		if shouldCloseStakeTx(blsIndex, len(si.blsPublicKeys)) {
+			stakeValue := big.NewInt(0).Mul(big.NewInt(int64(numStake)), cfg.nodePrice)
 			totalStakedValue.Add(totalStakedValue, stakeValue)
 			currentTx.Value = stakeValue.String()
 			currentTx.Data = []byte(fmt.Sprintf("stake@%x", big.NewInt(int64(numStake)).Bytes()) + string(currentTx.Data))
@@ -303,10 +431,17 @@ func processStake(si *stakeInfo, proxy interactors.Proxy, netConfig *data.Networ
 	requireNilErr(err)
 	log.Info("sent transactions as bunch", "tx hashes", txHashes)
 
-	waitForTransactionsToCompleteSuccessfully(proxy, txHashes)
+	err = waitForTransactionsToCompleteSuccessfully(proxy, netConfig, txHashes)
+	requireNilErr(err)
+}
+
+// shouldCloseStakeTx returns true if the stake transaction that just received the key at blsIndex is full and is not
+// the last one
+func shouldCloseStakeTx(blsIndex int, numKeys int) bool {
+	return blsIndex%50 == 0 && blsIndex+1 < numKeys && blsIndex > 0
 }
 
-func makeDelegationContract(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig) {
+func makeDelegationContract(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig) string {
 	log.Info("make delegation contract", "owner", si.walletKey.bech32Address)
 	holder, _ := cryptoProvider.NewCryptoComponentsHolder(walletKeyGen, si.walletKey.skBytes)
 	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
@@ -346,57 +481,17 @@ func makeDelegationContract(si *stakeInfo, proxy interactors.Proxy, netConfig *d
 		"receiver", tx.Receiver,
 		"data", string(tx.Data))
 
-	waitForTransactionToCompleteSuccessfully(proxy, hash[0])
-}
-
-func waitForTransactionToCompleteSuccessfully(proxy interactors.Proxy, hexTxHash string) {
-	processStatusProxyInstance := proxy.(processStatusProxy)
-
-	ctx, cancelFunc := context.WithTimeout(context.Background(), maxTimeoutForTransactionToComplete)
-	defer cancelFunc()
-
-	for {
-		status, err := processStatusProxyInstance.ProcessTransactionStatus(ctx, hexTxHash)
-		if err != nil {
-			log.Error("error getting transaction status", "tx hash", hexTxHash, "error", err)
-			panic(err)
-		}
-
-		if status == transaction.TxStatusSuccess {
-			return
-		}
-		if status == transaction.TxStatusPending {
-			time.Sleep(blockTime)
-			continue
-		}
-
-		log.Info("transaction failed", "tx hash", hexTxHash)
-		panic("transaction failed")
-	}
-}
+	err = waitForTransactionToCompleteSuccessfully(proxy, netConfig, hash[0])
+	requireNilErr(err)
 
-func waitForTransactionsToCompleteSuccessfully(proxy interactors.Proxy, hexTxHashes []string) {
-	for _, txHash := range hexTxHashes {
-		waitForTransactionToCompleteSuccessfully(proxy, txHash)
-	}
+	return hash[0]
 }
 
 func createTestnetProxy() interactors.Proxy {
-	args := blockchain.ArgsProxy{
-		ProxyURL:            gateway,
-		Client:              nil,
-		SameScState:         false,
-		ShouldBeSynced:      false,
-		FinalityCheck:       false,
-		CacheExpirationTime: time.Minute,
-		EntityType:          sdkCore.Proxy,
-	}
-	ep, err := blockchain.NewProxy(args)
-	if err != nil {
-		panic(err)
-	}
+	proxy, err := common.NewProxy(gateway)
+	requireNilErr(err)
 
-	return ep
+	return proxy
 }
 
 func requireNilErr(err error) {
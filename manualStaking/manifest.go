@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+)
+
+const manifestFilename = "manifest.json"
+
+// accountManifest holds the optional per-account settings applied after the delegation contract is created
+type accountManifest struct {
+	Name                        string `json:"name,omitempty"`
+	Website                     string `json:"website,omitempty"`
+	Identity                    string `json:"identity,omitempty"`
+	AutomaticActivation         *bool  `json:"automaticActivation,omitempty"`
+	CheckCapOnReDelegateRewards *bool  `json:"checkCapOnReDelegateRewards,omitempty"`
+	ServiceFee                  *int   `json:"serviceFee,omitempty"`         // in hundredths of a percent, 800 is 8.00%
+	TotalDelegationCap          string `json:"totalDelegationCap,omitempty"` // in EGLD, 0 means uncapped
+	RewardAddress               string `json:"rewardAddress,omitempty"`      // bech32, only for validators staked directly
+}
+
+// loadManifest reads the manifest from the provided account directory. A missing manifest yields an empty one.
+func loadManifest(dirPath string) *accountManifest {
+	manifest := &accountManifest{}
+	buff, err := os.ReadFile(path.Join(dirPath, manifestFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest
+	}
+	requireNilErr(err)
+
+	err = json.Unmarshal(buff, manifest)
+	requireNilErr(err)
+
+	return manifest
+}
+
+func (manifest *accountManifest) hasMetaData() bool {
+	return len(manifest.Name)+len(manifest.Website)+len(manifest.Identity) > 0
+}
+
+func (manifest *accountManifest) totalDelegationCapValue() (*big.Int, error) {
+	value, ok := big.NewInt(0).SetString(manifest.TotalDelegationCap, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid total delegation cap %s", manifest.TotalDelegationCap)
+	}
+
+	return value.Mul(value, oneELGD), nil
+}
+
+// withoutFeeAndCap returns a copy of the manifest without the service fee & delegation cap, for contracts that were
+// created with these values already set
+func (manifest *accountManifest) withoutFeeAndCap() *accountManifest {
+	manifestCopy := *manifest
+	manifestCopy.ServiceFee = nil
+	manifestCopy.TotalDelegationCap = ""
+
+	return &manifestCopy
+}
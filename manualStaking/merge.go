@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"math/big"
+
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+const whitelistForMergeGas = 5000000
+const mergeValidatorGas = 510000000
+
+// validatorStakeData is the result of the validator SC getTotalStakedTopUpStakedBlsKeys view
+type validatorStakeData struct {
+	topUp      *big.Int
+	totalStake *big.Int
+	numActive  int64
+	blsKeys    []string
+}
+
+// runMerge moves an existing standalone validator into an existing delegation contract. The contract owner whitelists
+// the validator owner, then the validator owner merges its validator data into the contract.
+func runMerge(args []string) {
+	flags := flag.NewFlagSet(commandMerge, flag.ExitOnError)
+	contractOwnerFile := flags.String("contract-owner", "", "the PEM file of the delegation contract owner")
+	validatorOwnerFile := flags.String("validator-owner", "", "the PEM file of the validator owner")
+	contract := flags.String("contract", "", "the delegation contract the validator is merged into")
+	_ = flags.Parse(args)
+
+	if len(*contractOwnerFile) == 0 || len(*validatorOwnerFile) == 0 || len(*contract) == 0 {
+		flags.Usage()
+		panic("the contract-owner, validator-owner & contract flags are required")
+	}
+
+	contractOwner := loadWalletKeyAddress(*contractOwnerFile)
+	validatorOwner := loadWalletKeyAddress(*validatorOwnerFile)
+	defer contractOwner.wipe()
+	defer validatorOwner.wipe()
+	contractAddress := *contract
+
+	proxy := createTestnetProxy()
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	checkMergePreconditions(proxy, contractOwner, validatorOwner, contractAddress)
+	contractStakeBefore, err := getValidatorStakeData(proxy, contractAddress)
+	if err != nil {
+		log.Info("delegation contract has no validator data yet", "contract", contractAddress, "reason", err.Error())
+		contractStakeBefore = &validatorStakeData{topUp: big.NewInt(0), totalStake: big.NewInt(0)}
+	}
+
+	whitelistValidatorForMerge(proxy, contractOwner, validatorOwner, contractAddress, netConfigs)
+	mergeValidatorToDelegation(proxy, validatorOwner, contractAddress, netConfigs)
+
+	checkMergeResult(proxy, validatorOwner, contractAddress, contractStakeBefore)
+}
+
+// checkMergePreconditions aborts if the contract is not owned by the contract owner or if the validator owner does not
+// have a validator with staked nodes
+func checkMergePreconditions(proxy interactors.Proxy, contractOwner *walletKeyAddress, validatorOwner *walletKeyAddress, contractAddress string) {
+	if contractOwner.bech32Address == validatorOwner.bech32Address {
+		panic("the contract owner can not whitelist its own address for merge")
+	}
+
+	requireContractOwner(proxy, contractAddress, contractOwner)
+
+	validatorData, err := getValidatorStakeData(proxy, validatorOwner.bech32Address)
+	if err != nil {
+		panic(fmt.Sprintf("%s does not have a validator to merge: %s", validatorOwner.bech32Address, err.Error()))
+	}
+	if validatorData.numActive == 0 {
+		panic(fmt.Sprintf("%s does not have any staked nodes", validatorOwner.bech32Address))
+	}
+
+	log.Info("validator to merge",
+		"owner", validatorOwner.bech32Address,
+		"total stake", validatorData.totalStake.String(),
+		"top up", validatorData.topUp.String(),
+		"num active nodes", validatorData.numActive)
+}
+
+func whitelistValidatorForMerge(
+	proxy interactors.Proxy,
+	contractOwner *walletKeyAddress,
+	validatorOwner *walletKeyAddress,
+	contractAddress string,
+	netConfig *data.NetworkConfig,
+) {
+	log.Info("whitelist for merge", "contract", contractAddress, "validator owner", validatorOwner.bech32Address)
+
+	txData := fmt.Sprintf("whitelistForMerge@%x", validatorOwner.address.AddressBytes())
+	sendTransactionAndWait(proxy, contractOwner, netConfig, contractAddress, big.NewInt(0), whitelistForMergeGas, txData)
+
+	returnData, err := executeVMQuery(proxy, contractAddress, contractOwner.bech32Address, "getWhitelistForMerge")
+	requireNilErr(err)
+	if len(returnData) == 0 || !bytes.Equal(returnData[0], validatorOwner.address.AddressBytes()) {
+		panic(fmt.Sprintf("%s is not whitelisted for merge in %s", validatorOwner.bech32Address, contractAddress))
+	}
+}
+
+func mergeValidatorToDelegation(proxy interactors.Proxy, validatorOwner *walletKeyAddress, contractAddress string, netConfig *data.NetworkConfig) {
+	log.Info("merge validator to delegation", "validator owner", validatorOwner.bech32Address, "contract", contractAddress)
+
+	delegationManagerAddress, _ := data.NewAddressFromBytes(vm.DelegationManagerSCAddress).AddressAsBech32String()
+	txData := fmt.Sprintf("mergeValidatorToDelegationWithWhitelist@%x", decodeBech32Address(contractAddress))
+	sendTransactionAndWait(proxy, validatorOwner, netConfig, delegationManagerAddress, big.NewInt(0), mergeValidatorGas, txData)
+}
+
+// checkMergeResult verifies that the validator nodes were moved in the contract and that the validator owner became
+// a delegator holding the former validator stake
+func checkMergeResult(proxy interactors.Proxy, validatorOwner *walletKeyAddress, contractAddress string, contractStakeBefore *validatorStakeData) {
+	contractStakeAfter, err := getValidatorStakeData(proxy, contractAddress)
+	requireNilErr(err)
+	if contractStakeAfter.numActive <= contractStakeBefore.numActive {
+		panic(fmt.Sprintf("the number of active nodes in %s did not increase after merge", contractAddress))
+	}
+
+	returnData, err := executeVMQuery(proxy, contractAddress, validatorOwner.bech32Address, "getUserActiveStake", validatorOwner.address.AddressBytes())
+	requireNilErr(err)
+	userActiveStake := big.NewInt(0)
+	if len(returnData) > 0 {
+		userActiveStake.SetBytes(returnData[0])
+	}
+
+	log.Info("merge done",
+		"contract", contractAddress,
+		"num active nodes before", contractStakeBefore.numActive,
+		"num active nodes after", contractStakeAfter.numActive,
+		"total stake after", contractStakeAfter.totalStake.String(),
+		"validator owner active stake", userActiveStake.String())
+}
+
+// getValidatorStakeData queries the validator SC for the stake of the provided validator, a wallet or a delegation contract
+func getValidatorStakeData(proxy interactors.Proxy, validatorAddress string) (*validatorStakeData, error) {
+	validatorSCAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	returnData, err := executeVMQuery(proxy, validatorSCAddress, validatorSCAddress, "getTotalStakedTopUpStakedBlsKeys", decodeBech32Address(validatorAddress))
+	if err != nil {
+		return nil, err
+	}
+	if len(returnData) < 3 {
+		return nil, fmt.Errorf("unexpected getTotalStakedTopUpStakedBlsKeys response for %s", validatorAddress)
+	}
+
+	stakeData := &validatorStakeData{
+		topUp:      big.NewInt(0).SetBytes(returnData[0]),
+		totalStake: big.NewInt(0).SetBytes(returnData[1]),
+		numActive:  big.NewInt(0).SetBytes(returnData[2]).Int64(),
+		blsKeys:    make([]string, 0, len(returnData)-3),
+	}
+	for _, key := range returnData[3:] {
+		stakeData.blsKeys = append(stakeData.blsKeys, fmt.Sprintf("%x", key))
+	}
+
+	return stakeData, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/blockchain/cryptoProvider"
+	"github.com/multiversx/mx-sdk-go/builders"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"github.com/multiversx/mx-sdk-go/workflows"
+)
+
+const mintGasLimit = 50000
+const mintData = "initial mint"
+
+// pendingMint tracks the confirmation of an account's mint transaction
+type pendingMint struct {
+	hash string
+	done chan struct{}
+	err  error
+}
+
+func (pm *pendingMint) wait() error {
+	<-pm.done
+
+	return pm.err
+}
+
+// processMints signs the mint transactions for all accounts with consecutive sponsor nonces, sends them as a bunch
+// and starts waiting for all of them. Each account receives the value of its checked funding. The returned map is
+// keyed by the minted account address.
+func processMints(
+	readStakeInfo []*stakeInfo,
+	proxy interactors.Proxy,
+	sponsorWallet *walletKeyAddress,
+	sponsorNonces *sponsorNonceManager,
+	fundings map[string]*accountFunding,
+	netConfig *data.NetworkConfig,
+) map[string]*pendingMint {
+	holder, _ := cryptoProvider.NewCryptoComponentsHolder(walletKeyGen, sponsorWallet.skBytes)
+	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
+	requireNilErr(err)
+
+	proxyHandler := proxy.(workflows.ProxyHandler)
+	txs := make([]*transaction.FrontendTransaction, 0, len(readStakeInfo))
+	for _, si := range readStakeInfo {
+		tx, _, errGetArgs := proxyHandler.GetDefaultTransactionArguments(context.Background(), sponsorWallet.address, netConfig)
+		requireNilErr(errGetArgs)
+
+		valueToMint := fundings[si.walletKey.bech32Address].toMint()
+		tx.Receiver = si.walletKey.bech32Address
+		tx.Value = valueToMint.String()
+		tx.Data = []byte(mintData)
+		tx.GasLimit = mintGasLimit + uint64(dataByteGasLimit*len(tx.Data))
+
+		txs = append(txs, &tx)
+	}
+
+	hashes, err := sponsorNonces.signAndSendAll(proxy, txBuilder, holder, txs)
+	requireNilErr(err)
+
+	mints := make(map[string]*pendingMint, len(txs))
+	for i, tx := range txs {
+		log.Info("generated & sent mint tx",
+			"hash", hashes[i],
+			"nonce", tx.Nonce,
+			"sender", tx.Sender,
+			"receiver", tx.Receiver,
+			"value", tx.Value)
+
+		mint := &pendingMint{
+			hash: hashes[i],
+			done: make(chan struct{}),
+		}
+		mints[tx.Receiver] = mint
+
+		go func() {
+			mint.err = waitForTransactionToCompleteSuccessfully(proxy, netConfig, mint.hash)
+			close(mint.done)
+		}()
+	}
+
+	return mints
+}
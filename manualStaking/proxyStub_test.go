@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	sdkCore "github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+var errTransactionNotFound = errors.New("transaction not found")
+
+// proxyStub answers the transaction status & info calls from fixed values, the other calls are not expected
+type proxyStub struct {
+	statuses     map[string]transaction.TxStatus
+	transactions map[string]data.TransactionOnNetwork
+}
+
+func (stub *proxyStub) ProcessTransactionStatus(_ context.Context, hexTxHash string) (transaction.TxStatus, error) {
+	status, found := stub.statuses[hexTxHash]
+	if !found {
+		return "", errTransactionNotFound
+	}
+
+	return status, nil
+}
+
+func (stub *proxyStub) GetTransactionInfo(_ context.Context, hash string) (*data.TransactionInfo, error) {
+	tx, found := stub.transactions[hash]
+	if !found {
+		return nil, errTransactionNotFound
+	}
+
+	txInfo := &data.TransactionInfo{}
+	txInfo.Data.Transaction = tx
+
+	return txInfo, nil
+}
+
+func (stub *proxyStub) GetTransactionInfoWithResults(ctx context.Context, hash string) (*data.TransactionInfo, error) {
+	return stub.GetTransactionInfo(ctx, hash)
+}
+
+func (stub *proxyStub) GetNetworkConfig(_ context.Context) (*data.NetworkConfig, error) {
+	panic("not expected")
+}
+
+func (stub *proxyStub) GetAccount(_ context.Context, _ sdkCore.AddressHandler) (*data.Account, error) {
+	panic("not expected")
+}
+
+func (stub *proxyStub) SendTransaction(_ context.Context, _ *transaction.FrontendTransaction) (string, error) {
+	panic("not expected")
+}
+
+func (stub *proxyStub) SendTransactions(_ context.Context, _ []*transaction.FrontendTransaction) ([]string, error) {
+	panic("not expected")
+}
+
+func (stub *proxyStub) IsInterfaceNil() bool {
+	return stub == nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/queries"
+)
+
+// executeVMQuery runs a view function on the provided contract and returns the raw return data
+func executeVMQuery(proxy interactors.Proxy, scAddress string, caller string, funcName string, args ...[]byte) ([][]byte, error) {
+	return queries.ExecuteVMQuery(proxy.(queries.VMQueryProxy), scAddress, caller, funcName, args...)
+}
+
+// requireContractOwner aborts before sending an owner only call if the wallet does not own the contract
+func requireContractOwner(proxy interactors.Proxy, contractAddress string, owner *walletKeyAddress) {
+	err := queries.CheckContractOwner(proxy.(queries.ContractOwnerProxy), contractAddress, owner.address)
+	requireNilErr(err)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+// runChangeRewardAddress sends changeRewardAddress on the validator SC for every account having a reward address in
+// its manifest and then reads the reward address of each staked key back. Only validators staked directly by the
+// owner wallet can change it, a validator converted into a delegation contract pays the rewards to the contract.
+func runChangeRewardAddress(args []string) {
+	flags := flag.NewFlagSet(commandRewards, flag.ExitOnError)
+	owners := flags.String("owners", "", "comma separated owner addresses to process, defaults to all accounts with a reward address")
+	numWorkers := flags.Int("workers", 1, "the number of accounts processed in parallel")
+	_ = flags.Parse(args)
+
+	readStakeInfo := filterRewardAddressAccounts(readDirStakeInfo(), *owners)
+	if len(readStakeInfo) == 0 {
+		log.Info("no account with a reward address in its manifest")
+		return
+	}
+
+	proxy := createTestnetProxy()
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	requireNilErr(err)
+
+	failed := processStakeInfosInParallel(readStakeInfo, *numWorkers, func(si *stakeInfo) {
+		processChangeRewardAddress(si, proxy, netConfigs)
+	})
+	if len(failed) > 0 {
+		panic(fmt.Sprintf("changeRewardAddress failed for %d account(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+}
+
+// filterRewardAddressAccounts keeps the accounts having a reward address in their manifest and, if provided, found in
+// the comma separated owners list
+func filterRewardAddressAccounts(readStakeInfo []*stakeInfo, owners string) []*stakeInfo {
+	selected := make(map[string]bool)
+	for _, owner := range strings.Split(owners, ",") {
+		owner = strings.TrimSpace(owner)
+		if len(owner) > 0 {
+			selected[owner] = true
+		}
+	}
+
+	filtered := make([]*stakeInfo, 0, len(readStakeInfo))
+	for _, si := range readStakeInfo {
+		if len(selected) > 0 && !selected[si.walletKey.bech32Address] {
+			continue
+		}
+		if len(si.manifest.RewardAddress) == 0 {
+			log.Info("no reward address in manifest, skipping", "owner", si.walletKey.bech32Address)
+			continue
+		}
+
+		filtered = append(filtered, si)
+	}
+
+	return filtered
+}
+
+func processChangeRewardAddress(si *stakeInfo, proxy interactors.Proxy, netConfig *data.NetworkConfig) {
+	log.Info("")
+	log.Info("############### changing reward address for " + si.walletKey.bech32Address + " ###############")
+
+	rewardAddress := decodeBech32Address(si.manifest.RewardAddress)
+	stakeData, err := getValidatorStakeData(proxy, si.walletKey.bech32Address)
+	if err != nil {
+		panic(fmt.Sprintf("%s is not a validator, if it was converted into a delegation contract the rewards go to the contract: %s",
+			si.walletKey.bech32Address, err.Error()))
+	}
+	if len(stakeData.blsKeys) == 0 {
+		panic(fmt.Sprintf("%s does not have any staked keys", si.walletKey.bech32Address))
+	}
+
+	numChanged := countKeysWithRewardAddress(proxy, si.walletKey.bech32Address, stakeData.blsKeys, rewardAddress)
+	if numChanged == len(stakeData.blsKeys) {
+		log.Info("reward address already set", "owner", si.walletKey.bech32Address, "reward address", si.manifest.RewardAddress)
+		return
+	}
+
+	validatorAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	txData := "changeRewardAddress@" + hex.EncodeToString(rewardAddress)
+	gasLimit := nodesTxGasLimit(len(stakeData.blsKeys))
+	sendTransactionAndWait(proxy, si.walletKey, netConfig, validatorAddress, big.NewInt(0), gasLimit, txData)
+
+	numChanged = countKeysWithRewardAddress(proxy, si.walletKey.bech32Address, stakeData.blsKeys, rewardAddress)
+	if numChanged != len(stakeData.blsKeys) {
+		panic(fmt.Sprintf("only %d out of %d keys of %s have the reward address %s",
+			numChanged, len(stakeData.blsKeys), si.walletKey.bech32Address, si.manifest.RewardAddress))
+	}
+
+	log.Info("reward address changed",
+		"owner", si.walletKey.bech32Address,
+		"reward address", si.manifest.RewardAddress,
+		"num keys", numChanged)
+}
+
+// countKeysWithRewardAddress returns how many of the keys have the provided reward address in the staking SC
+func countKeysWithRewardAddress(proxy interactors.Proxy, caller string, blsKeys []string, rewardAddress []byte) int {
+	stakingAddress, _ := data.NewAddressFromBytes(vm.StakingSCAddress).AddressAsBech32String()
+	expected := hex.EncodeToString(rewardAddress)
+
+	numMatching := 0
+	for _, blsKey := range blsKeys {
+		// the staking SC returns the reward address hex encoded
+		returnData, err := executeVMQuery(proxy, stakingAddress, caller, "getRewardAddress", decodeHexKey(blsKey))
+		requireNilErr(err)
+		if len(returnData) > 0 && string(returnData[0]) == expected {
+			numMatching++
+		}
+	}
+
+	return numMatching
+}
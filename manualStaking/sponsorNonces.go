@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	sdkCore "github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+const maxTransactionsInBunch = 100
+
+// sponsorNonceManager hands out the sponsor nonces so that the sponsor transactions never collide or leave nonce gaps
+type sponsorNonceManager struct {
+	mut       sync.Mutex
+	nextNonce uint64
+}
+
+func newSponsorNonceManager(proxy interactors.Proxy, sponsorWallet *walletKeyAddress) *sponsorNonceManager {
+	account, err := proxy.GetAccount(context.Background(), sponsorWallet.address)
+	requireNilErr(err)
+
+	return &sponsorNonceManager{
+		nextNonce: account.Nonce,
+	}
+}
+
+// signAndSendAll applies consecutive sponsor nonces on the transactions, signs them and sends them in bunches while
+// holding the lock. The nonces are consumed only for the bunches fully accepted by the proxy.
+func (snm *sponsorNonceManager) signAndSendAll(
+	proxy interactors.Proxy,
+	txBuilder interactors.TxBuilder,
+	holder sdkCore.CryptoComponentsHolder,
+	txs []*transaction.FrontendTransaction,
+) ([]string, error) {
+	snm.mut.Lock()
+	defer snm.mut.Unlock()
+
+	hashes := make([]string, 0, len(txs))
+	for start := 0; start < len(txs); start += maxTransactionsInBunch {
+		end := start + maxTransactionsInBunch
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		bunch := txs[start:end]
+		for i, tx := range bunch {
+			tx.Nonce = snm.nextNonce + uint64(i)
+			err := txBuilder.ApplyUserSignature(holder, tx)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		bunchHashes, err := proxy.SendTransactions(context.Background(), bunch)
+		if err != nil {
+			return nil, err
+		}
+		if len(bunchHashes) != len(bunch) {
+			return nil, fmt.Errorf("the proxy accepted only %d out of %d transactions starting with nonce %d",
+				len(bunchHashes), len(bunch), snm.nextNonce)
+		}
+
+		snm.nextNonce += uint64(len(bunch))
+		hashes = append(hashes, bunchHashes...)
+	}
+
+	return hashes, nil
+}
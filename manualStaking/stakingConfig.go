@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/queries"
+)
+
+// stakingConfig holds the on-chain values that govern how much EGLD has to be locked for each node. minDeposit is the
+// delegation manager minimum for the initial deposit of a contract created with createNewDelegationContract.
+type stakingConfig struct {
+	nodePrice           *big.Int
+	minDeposit          *big.Int
+	minDelegationAmount *big.Int
+}
+
+func readStakingConfig(proxy interactors.Proxy) *stakingConfig {
+	nodePrice, err := queries.GetNodePrice(proxy.(queries.EpochStartProxy))
+	requireNilErr(err)
+
+	// the delegation manager only answers getContractConfig if the caller is the contract itself
+	delegationManagerAddress, _ := data.NewAddressFromBytes(vm.DelegationManagerSCAddress).AddressAsBech32String()
+	returnData, err := executeVMQuery(proxy, delegationManagerAddress, delegationManagerAddress, "getContractConfig")
+	requireNilErr(err)
+	if len(returnData) < 6 {
+		requireNilErr(fmt.Errorf("invalid delegation manager config, expected 6 values, got %d", len(returnData)))
+	}
+
+	cfg := &stakingConfig{
+		nodePrice:           nodePrice,
+		minDeposit:          big.NewInt(0).SetBytes(returnData[4]),
+		minDelegationAmount: big.NewInt(0).SetBytes(returnData[5]),
+	}
+
+	log.Info("read staking config",
+		"node price", cfg.nodePrice.String(),
+		"min deposit", cfg.minDeposit.String(),
+		"min delegation amount", cfg.minDelegationAmount.String())
+
+	return cfg
+}
+
+// checkStakeValues aborts if any account does not hold enough stake for all its BLS keys
+func checkStakeValues(readStakeInfo []*stakeInfo, cfg *stakingConfig) {
+	numInvalid := 0
+	for _, si := range readStakeInfo {
+		required := big.NewInt(0).Mul(big.NewInt(int64(len(si.blsPublicKeys))), cfg.nodePrice)
+		topUp := big.NewInt(0).Sub(si.stakeValue, required)
+		if topUp.Sign() < 0 {
+			log.Error("stake value is below the node price for all keys",
+				"owner", si.walletKey.bech32Address,
+				"num keys", len(si.blsPublicKeys),
+				"stake value", si.stakeValue.String(),
+				"required", required.String())
+			numInvalid++
+			continue
+		}
+
+		log.Info("stake value check",
+			"owner", si.walletKey.bech32Address,
+			"num keys", len(si.blsPublicKeys),
+			"stake value", si.stakeValue.String(),
+			"locked for nodes", required.String(),
+			"top-up", topUp.String())
+	}
+
+	if numInvalid > 0 {
+		panic(fmt.Sprintf("%d account(s) do not have a valid stake value", numInvalid))
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/multiversx/mx-sdk-go/blockchain/cryptoProvider"
+	"github.com/multiversx/mx-sdk-go/builders"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"github.com/multiversx/mx-sdk-go/workflows"
+)
+
+// sendTransactionAndWait signs & sends a single transaction from the provided wallet using its current account nonce
+// and blocks until the transaction is executed successfully. The data field gas cost is added on top of gasLimit.
+func sendTransactionAndWait(
+	proxy interactors.Proxy,
+	wallet *walletKeyAddress,
+	netConfig *data.NetworkConfig,
+	receiver string,
+	value *big.Int,
+	gasLimit uint64,
+	txData string,
+) string {
+	holder, _ := cryptoProvider.NewCryptoComponentsHolder(walletKeyGen, wallet.skBytes)
+	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
+	requireNilErr(err)
+
+	ti, err := interactors.NewTransactionInteractor(proxy, txBuilder)
+	requireNilErr(err)
+
+	proxyHandler := proxy.(workflows.ProxyHandler)
+	tx, _, err := proxyHandler.GetDefaultTransactionArguments(context.Background(), wallet.address, netConfig)
+	requireNilErr(err)
+
+	account, err := proxy.GetAccount(context.Background(), wallet.address)
+	requireNilErr(err)
+
+	tx.Nonce = account.Nonce
+	tx.Receiver = receiver
+	tx.Value = value.String()
+	tx.Data = []byte(txData)
+	tx.GasLimit = gasLimit + uint64(dataByteGasLimit*len(tx.Data))
+
+	err = ti.ApplyUserSignature(holder, &tx)
+	requireNilErr(err)
+
+	ti.AddTransaction(&tx)
+	hash, err := ti.SendTransactionsAsBunch(context.Background(), 1)
+	requireNilErr(err)
+
+	log.Info("generated & sent tx",
+		"hash", hash[0],
+		"nonce", tx.Nonce,
+		"sender", tx.Sender,
+		"receiver", tx.Receiver,
+		"data", string(tx.Data))
+
+	err = waitForTransactionToCompleteSuccessfully(proxy, netConfig, hash[0])
+	requireNilErr(err)
+
+	return hash[0]
+}
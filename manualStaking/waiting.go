@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core"
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+)
+
+// roundsPerHop is the number of rounds a transaction is given to get from one shard to the next, including the
+// block finality on both sides
+const roundsPerHop = 20
+const maxNumHops = 3
+
+// waitForMetaNotarization, when set, makes a transaction count as successful only after its block was notarized by
+// the metachain
+var waitForMetaNotarization = false
+
+type transactionDetailsProxy interface {
+	GetTransactionInfo(ctx context.Context, hash string) (*data.TransactionInfo, error)
+}
+
+// transactionPendingError is returned when a transaction did not complete before its deadline. It holds what was
+// known about the transaction at that moment.
+type transactionPendingError struct {
+	hash             string
+	status           transaction.TxStatus
+	sourceShard      uint32
+	destinationShard uint32
+	numHops          int
+	blockNonce       uint64
+	notarizedInMeta  bool
+	elapsed          time.Duration
+	deadline         time.Duration
+}
+
+// Error returns the pending report as a single line
+func (err *transactionPendingError) Error() string {
+	return fmt.Sprintf("transaction %s still %s after %s (deadline %s): shard %d -> %d, %d hop(s), block nonce %d, notarized in meta %v",
+		err.hash, err.status, err.elapsed.Truncate(time.Second), err.deadline, err.sourceShard, err.destinationShard,
+		err.numHops, err.blockNonce, err.notarizedInMeta)
+}
+
+func (err *transactionPendingError) logReport() {
+	log.Error("transaction did not complete in time",
+		"tx hash", err.hash,
+		"status", err.status,
+		"source shard", err.sourceShard,
+		"destination shard", err.destinationShard,
+		"num hops", err.numHops,
+		"block nonce", err.blockNonce,
+		"notarized in meta", err.notarizedInMeta,
+		"elapsed", err.elapsed.Truncate(time.Second),
+		"deadline", err.deadline)
+}
+
+// waitForTransactionToCompleteSuccessfully blocks until the transaction is executed, polling once per round. The
+// deadline is scaled to the number of shard hops the transaction needs. A *transactionFailedError holding the decoded
+// failure reasons is returned if the transaction did not succeed and a *transactionPendingError if it did not complete
+// in time.
+func waitForTransactionToCompleteSuccessfully(proxy interactors.Proxy, netConfig *data.NetworkConfig, hexTxHash string) error {
+	roundDuration := time.Duration(netConfig.RoundDuration) * time.Millisecond
+	pending := &transactionPendingError{
+		hash:    hexTxHash,
+		status:  transaction.TxStatusPending,
+		numHops: maxNumHops,
+	}
+	updatePendingReport(proxy, pending)
+
+	pending.deadline = time.Duration(pending.numHops*roundsPerHop) * roundDuration
+	if waitForMetaNotarization {
+		pending.deadline += roundsPerHop * roundDuration
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), pending.deadline)
+	defer cancelFunc()
+
+	start := time.Now()
+	for {
+		pending.elapsed = time.Since(start)
+		if pending.elapsed > pending.deadline {
+			updatePendingReport(proxy, pending)
+			pending.logReport()
+
+			return pending
+		}
+
+		status, err := proxy.(processStatusProxy).ProcessTransactionStatus(ctx, hexTxHash)
+		if err != nil && ctx.Err() == nil {
+			log.Error("error getting transaction status", "tx hash", hexTxHash, "error", err)
+			return fmt.Errorf("%w while getting the status of transaction %s", err, hexTxHash)
+		}
+		if err == nil {
+			pending.status = status
+		}
+
+		switch {
+		case err != nil, status == transaction.TxStatusPending:
+		case status == transaction.TxStatusSuccess:
+			if !waitForMetaNotarization {
+				return nil
+			}
+
+			updatePendingReport(proxy, pending)
+			if pending.notarizedInMeta {
+				return nil
+			}
+		default:
+			txErr := newTransactionFailedError(proxy, hexTxHash, status)
+			log.Error("transaction failed", "tx hash", hexTxHash, "status", status, "reason", strings.Join(txErr.reasons, "; "))
+
+			return txErr
+		}
+
+		time.Sleep(roundDuration)
+	}
+}
+
+// updatePendingReport refreshes the shards, hops and notarization info of the pending transaction. The previous values
+// are kept if the transaction can not be fetched.
+func updatePendingReport(proxy interactors.Proxy, pending *transactionPendingError) {
+	txInfo, err := proxy.(transactionDetailsProxy).GetTransactionInfo(context.Background(), pending.hash)
+	if err != nil {
+		log.Debug("can not fetch the transaction", "tx hash", pending.hash, "error", err)
+		return
+	}
+
+	tx := txInfo.Data.Transaction
+	pending.sourceShard = tx.SourceShard
+	pending.destinationShard = tx.DestinationShard
+	pending.blockNonce = tx.BlockNonce
+	pending.notarizedInMeta = tx.HyperBlockNonce > 0 || tx.NotarizedAtDestinationInMetaNonce > 0
+	pending.numHops = numShardHops(tx)
+}
+
+// numShardHops returns 1 for intra shard transactions, 2 for cross shard transfers and 3 for cross shard smart
+// contract calls, whose results travel back to the sender's shard
+func numShardHops(tx data.TransactionOnNetwork) int {
+	if tx.SourceShard == tx.DestinationShard {
+		return 1
+	}
+
+	receiver, err := data.NewAddressFromBech32String(tx.Receiver)
+	if err != nil || core.IsSmartContractAddress(receiver.AddressBytes()) {
+		return maxNumHops
+	}
+
+	return 2
+}
+
+func waitForTransactionsToCompleteSuccessfully(proxy interactors.Proxy, netConfig *data.NetworkConfig, hexTxHashes []string) error {
+	for _, txHash := range hexTxHashes {
+		err := waitForTransactionToCompleteSuccessfully(proxy, netConfig, txHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
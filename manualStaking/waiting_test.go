@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+const pendingTxHash = "9a4b11c7"
+const userAddress = "erd1vqw75zwpnxnpzkkp55a9accndh56qqclyhgu2lddhwv0hhn80uqsfgjs94"
+const contractAddress = "erd1qqqqqqqqqqqqqpgq97wezxw6l7lgg7k9rxvycrz66vn92ksh2tssxwf7ep"
+
+// the stub rounds last a millisecond so a hop is 20 milliseconds
+var testNetConfig = &data.NetworkConfig{RoundDuration: 1}
+
+func waitForStubTransaction(status transaction.TxStatus, tx data.TransactionOnNetwork) error {
+	proxy := &proxyStub{
+		statuses:     map[string]transaction.TxStatus{pendingTxHash: status},
+		transactions: map[string]data.TransactionOnNetwork{pendingTxHash: tx},
+	}
+
+	return waitForTransactionToCompleteSuccessfully(proxy, testNetConfig, pendingTxHash)
+}
+
+func TestPendingDeadlineScalesWithTheShardHops(t *testing.T) {
+	tests := []struct {
+		name           string
+		tx             data.TransactionOnNetwork
+		expectedHops   int
+		expectedRounds int
+	}{
+		{
+			name:           "intra shard call",
+			tx:             data.TransactionOnNetwork{SourceShard: 1, DestinationShard: 1, Receiver: contractAddress},
+			expectedHops:   1,
+			expectedRounds: roundsPerHop,
+		},
+		{
+			name:           "cross shard transfer",
+			tx:             data.TransactionOnNetwork{SourceShard: 0, DestinationShard: 1, Receiver: userAddress},
+			expectedHops:   2,
+			expectedRounds: 2 * roundsPerHop,
+		},
+		{
+			name:           "cross shard contract call",
+			tx:             data.TransactionOnNetwork{SourceShard: 0, DestinationShard: 1, Receiver: contractAddress},
+			expectedHops:   3,
+			expectedRounds: 3 * roundsPerHop,
+		},
+		{
+			name:           "cross shard to an unreadable receiver",
+			tx:             data.TransactionOnNetwork{SourceShard: 0, DestinationShard: 1, Receiver: "erd1invalid"},
+			expectedHops:   maxNumHops,
+			expectedRounds: maxNumHops * roundsPerHop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := waitForStubTransaction(transaction.TxStatusPending, tt.tx)
+
+			var pending *transactionPendingError
+			if !errors.As(err, &pending) {
+				t.Fatalf("expected a *transactionPendingError, got %v", err)
+			}
+			if pending.numHops != tt.expectedHops {
+				t.Errorf("expected %d hops, got %d", tt.expectedHops, pending.numHops)
+			}
+			if pending.deadline != time.Duration(tt.expectedRounds)*time.Millisecond {
+				t.Errorf("expected a %d rounds deadline, got %s", tt.expectedRounds, pending.deadline)
+			}
+			if pending.elapsed <= pending.deadline {
+				t.Errorf("gave up after %s, before the %s deadline", pending.elapsed, pending.deadline)
+			}
+		})
+	}
+}
+
+func TestWaitForMetaNotarization(t *testing.T) {
+	waitForMetaNotarization = true
+	defer func() {
+		waitForMetaNotarization = false
+	}()
+
+	intraShard := data.TransactionOnNetwork{SourceShard: 1, DestinationShard: 1, Receiver: userAddress, BlockNonce: 42}
+	err := waitForStubTransaction(transaction.TxStatusSuccess, intraShard)
+
+	var pending *transactionPendingError
+	if !errors.As(err, &pending) {
+		t.Fatalf("a successful transaction not notarized in meta should time out, got %v", err)
+	}
+	if pending.notarizedInMeta || pending.blockNonce != 42 {
+		t.Errorf("unexpected pending report %s", pending.Error())
+	}
+	if pending.deadline != 2*roundsPerHop*time.Millisecond {
+		t.Errorf("the notarization should add a hop to the deadline, got %s", pending.deadline)
+	}
+
+	intraShard.HyperBlockNonce = 43
+	err = waitForStubTransaction(transaction.TxStatusSuccess, intraShard)
+	if err != nil {
+		t.Errorf("a transaction notarized in meta should succeed, got %v", err)
+	}
+}
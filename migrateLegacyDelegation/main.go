@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"math/big"
+	"time"
+
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-sdk-go/examples"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+	"v1/keys"
+	"v1/queries"
+)
+
+const walletFilename = "./legacyDelegationOwner.pem"
+const validatorsKeysFilename = "./all.pem"
+const stateFilename = "./migration.json"
+const defaultServiceFee = 800 // 8.00%
+
+var log = logger.GetOrCreate("migrateLegacyDelegation")
+
+// migrateLegacyDelegation moves a provider from a legacy delegation contract to a new system delegation contract. Each
+// run advances the migration as far as the chain allows and saves the progress, so it can be run again, or with
+// -watch, until all the steps are done.
+func main() {
+	walletFile := flag.String("wallet", walletFilename, "the wallet PEM or JSON keystore file of the legacy contract owner")
+	passwordFile := flag.String("password-file", "", "the file holding the password shared by the key files, "+
+		"defaults to the "+keys.PasswordEnvVariable+" variable or an interactive prompt")
+	passwordFiles := keys.PasswordFiles{}
+	flag.Var(passwordFiles, "file-password", keys.PasswordFilesUsage)
+	contract := flag.String("contract", "", "the legacy delegation contract to migrate from, required")
+	blsKeysFile := flag.String("bls-keys", validatorsKeysFilename, "the validators PEM file or encrypted BLS container of the migrated nodes")
+	stateFile := flag.String("state-file", stateFilename, "the file the migration progress is saved in")
+	status := flag.Bool("status", false, "only print the migration status")
+	watch := flag.Bool("watch", false, "keep advancing the migration until all the steps are done")
+	pollInterval := flag.Duration("poll-interval", 10*time.Minute, "how often the migration is advanced in watch mode")
+	retry := flag.Bool("retry", false, "send the transactions of the current step again, after they failed")
+	delegationCap := flag.String("delegation-cap", "0", "the total delegation cap of the new contract in EGLD, 0 for no cap")
+	serviceFee := flag.Int("service-fee", defaultServiceFee, "the service fee of the new contract, in hundredths of a percent")
+	flag.Parse()
+
+	state, err := loadMigrationState(*stateFile)
+	if err != nil {
+		panic(err)
+	}
+	if *status {
+		state.printStatus()
+		return
+	}
+	if len(*contract) == 0 {
+		panic("no contract provided, use -contract")
+	}
+
+	capValue, err := common.ParseEGLDValue(*delegationCap)
+	if err != nil {
+		panic(err)
+	}
+
+	passwords := keys.NewPasswordProvider(*passwordFile, passwordFiles)
+	skBytes, err := keys.LoadWalletKey(*walletFile, passwords)
+	if err != nil {
+		panic(err)
+	}
+	defer keys.Wipe(skBytes)
+
+	ownerAddress, err := interactors.NewWallet().GetAddressFromPrivateKey(skBytes)
+	if err != nil {
+		panic(err)
+	}
+
+	proxy, err := common.NewProxy(examples.TestnetGateway)
+	if err != nil {
+		panic(err)
+	}
+	err = queries.CheckContractOwner(proxy.(queries.ContractOwnerProxy), *contract, ownerAddress)
+	if err != nil {
+		panic(err)
+	}
+
+	m, err := newMigration(proxy, *contract, ownerAddress, skBytes, *blsKeysFile, passwords, state)
+	if err != nil {
+		panic(err)
+	}
+	m.delegationCap = capValue
+	m.serviceFee = big.NewInt(int64(*serviceFee))
+
+	for {
+		done, errAdvance := m.advance(*stateFile, *retry)
+		if errAdvance != nil {
+			log.Error("migration step failed", "error", errAdvance)
+		}
+		m.state.printStatus()
+		if done || !*watch {
+			return
+		}
+
+		*retry = false
+		time.Sleep(*pollInterval)
+	}
+}
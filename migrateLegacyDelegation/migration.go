@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+	"v1/keys"
+	"v1/operations"
+	"v1/queries"
+)
+
+// maxKeysPerTx bounds the keys of a single stake transaction
+const maxKeysPerTx = 40
+
+const ownerUnStakeGas = 100000000
+const ownerUnBondGas = 100000000
+const baseStakeGas = 50000000
+const stakeGasPerNode = 6000000
+const makeContractGas = 510000000
+
+const unBondOwnerStakeStep = "unBondOwnerStake"
+
+// maxStepAttempts is how many times the transactions of a step are sent before waiting for the retry flag
+const maxStepAttempts = 3
+
+// maxScannedContracts bounds how many of the newest delegation contracts are searched for the migrated one
+const maxScannedContracts = 100
+
+// migrationStep is one step of the migration. check reads the chain and returns true once the step is done, together
+// with a short description of its progress. send, if set, issues the transactions of the step.
+type migrationStep struct {
+	name  string
+	check func(m *migration) (bool, string, error)
+	send  func(m *migration) ([]string, error)
+}
+
+var migrationSteps = []*migrationStep{
+	{
+		name:  "unStakeLegacyNodes",
+		check: (*migration).checkLegacyNodesUnStaked,
+		send:  (*migration).sendLegacyUnStakeNodes,
+	},
+	{
+		name:  "waitLegacyUnBondPeriod",
+		check: (*migration).checkUnBondPeriodPassed,
+	},
+	{
+		name:  "unBondLegacyNodes",
+		check: (*migration).checkLegacyNodesUnBonded,
+		send:  (*migration).sendLegacyUnBondNodes,
+	},
+	{
+		name:  "unStakeOwnerStake",
+		check: (*migration).checkOwnerStakeUnStaked,
+		send:  (*migration).sendOwnerUnStake,
+	},
+	{
+		name:  unBondOwnerStakeStep,
+		check: (*migration).checkOwnerStakeWithdrawn,
+		send:  (*migration).sendOwnerUnBond,
+	},
+	{
+		name:  "stakeOnValidatorSC",
+		check: (*migration).checkNodesStakedOnValidatorSC,
+		send:  (*migration).sendValidatorStake,
+	},
+	{
+		name:  "createSystemDelegation",
+		check: (*migration).checkSystemDelegationCreated,
+		send:  (*migration).sendMakeNewContractFromValidatorData,
+	},
+}
+
+type migration struct {
+	proxy         interactors.Proxy
+	contract      string
+	ownerAddress  core.AddressHandler
+	owner         string
+	skBytes       []byte
+	blsKeysFile   string
+	passwords     *keys.PasswordProvider
+	state         *migrationState
+	delegationCap *big.Int
+	serviceFee    *big.Int
+}
+
+// newMigration binds the migration to the contract, the owner and the BLS keys on its first run and checks, on the
+// following runs, that the same contract & owner are used
+func newMigration(
+	proxy interactors.Proxy,
+	contract string,
+	ownerAddress core.AddressHandler,
+	skBytes []byte,
+	blsKeysFile string,
+	passwords *keys.PasswordProvider,
+	state *migrationState,
+) (*migration, error) {
+	owner, err := ownerAddress.AddressAsBech32String()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(state.Contract) == 0 {
+		blsPrivateKeys, blsPublicKeys, errLoad := keys.LoadBlsKeys(blsKeysFile, passwords)
+		if errLoad != nil {
+			return nil, errLoad
+		}
+		keys.WipeAll(blsPrivateKeys)
+		if len(blsPublicKeys) == 0 {
+			return nil, fmt.Errorf("no BLS keys in %s", blsKeysFile)
+		}
+
+		state.Contract = contract
+		state.Owner = owner
+		state.BlsKeys = blsPublicKeys
+	}
+	if state.Contract != contract || state.Owner != owner {
+		return nil, fmt.Errorf("the saved migration is for contract %s owned by %s, not for contract %s owned by %s",
+			state.Contract, state.Owner, contract, owner)
+	}
+
+	return &migration{
+		proxy:         proxy,
+		contract:      contract,
+		ownerAddress:  ownerAddress,
+		owner:         owner,
+		skBytes:       skBytes,
+		blsKeysFile:   blsKeysFile,
+		passwords:     passwords,
+		state:         state,
+		delegationCap: big.NewInt(0),
+		serviceFee:    big.NewInt(defaultServiceFee),
+	}, nil
+}
+
+// advance walks the steps in order, marks the done ones and sends the transactions of the first step that is not done.
+// The transactions of a step are sent once. A step whose transactions failed is marked failed and sent again on the
+// next advance, up to maxStepAttempts times, after which it is sent again only with retry. It returns true when all
+// the steps are done.
+func (m *migration) advance(stateFile string, retry bool) (bool, error) {
+	for i, step := range migrationSteps {
+		progress := m.state.Steps[i]
+		if progress.Status == stepStatusDone {
+			continue
+		}
+
+		done, detail, err := step.check(m)
+		if err != nil {
+			progress.Detail = err.Error()
+			return false, m.saveWithError(stateFile, fmt.Errorf("%w while checking %s", err, step.name))
+		}
+		if done {
+			progress.complete(detail)
+			log.Info("migration step done", "step", step.name, "detail", detail)
+			continue
+		}
+
+		progress.start()
+		progress.Detail = detail
+		if progress.Status == stepStatusSent {
+			m.checkSentTransactions(progress)
+		}
+		if progress.Status == stepStatusFailed {
+			progress.Detail = fmt.Sprintf("%s, transaction %s failed", detail, progress.FailedTxHash)
+		}
+
+		canSend := progress.Status == stepStatusPending || progress.Status == stepStatusWaiting || retry ||
+			(progress.Status == stepStatusFailed && progress.Attempts < maxStepAttempts)
+		if step.send == nil || !canSend {
+			if progress.Status == stepStatusPending {
+				progress.Status = stepStatusWaiting
+			}
+			return false, common.SaveJSONFile(stateFile, m.state)
+		}
+
+		hashes, err := step.send(m)
+		if err != nil {
+			progress.Detail = err.Error()
+			return false, m.saveWithError(stateFile, fmt.Errorf("%w while sending %s", err, step.name))
+		}
+		if len(hashes) == 0 {
+			progress.Status = stepStatusWaiting
+			return false, common.SaveJSONFile(stateFile, m.state)
+		}
+
+		progress.Attempts++
+		progress.TxHashes = hashes
+		progress.FailedTxHash = ""
+		progress.SentAt = time.Now().UTC().Format(time.RFC3339)
+		progress.Status = stepStatusSent
+		log.Info("migration step sent", "step", step.name, "tx hashes", hashes)
+
+		return false, common.SaveJSONFile(stateFile, m.state)
+	}
+
+	return true, common.SaveJSONFile(stateFile, m.state)
+}
+
+// checkSentTransactions marks the step failed, so the status view shows it, when one of its transactions did not succeed
+func (m *migration) checkSentTransactions(progress *stepProgress) {
+	sentAt, _ := time.Parse(time.RFC3339, progress.SentAt)
+	failedHash, err := queries.FindFailedTransaction(m.proxy.(queries.TransactionStatusProxy), progress.TxHashes, sentAt)
+	if err != nil {
+		log.Debug("can not read the transactions status", "step", progress.Name, "error", err)
+	}
+	if len(failedHash) == 0 {
+		return
+	}
+
+	progress.Status = stepStatusFailed
+	progress.FailedTxHash = failedHash
+	log.Warn("migration step transaction failed", "step", progress.Name, "tx hash", failedHash, "attempts", progress.Attempts)
+}
+
+func (m *migration) saveWithError(stateFile string, err error) error {
+	errSave := common.SaveJSONFile(stateFile, m.state)
+	if errSave != nil {
+		log.Error("can not save the migration state", "error", errSave)
+	}
+
+	return err
+}
+
+// checkLegacyNodesUnStaked is done when none of the keys is still staked or queued. A jailed key can be neither unstaked
+// nor unbonded so the migration stops until it is unjailed.
+func (m *migration) checkLegacyNodesUnStaked() (bool, string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return false, "", err
+	}
+
+	jailedKeys := m.keysInStatus(statuses, queries.StakingStatusJailed)
+	if len(jailedKeys) > 0 {
+		return false, "", fmt.Errorf("%d key(s) are jailed, unjail them in the legacy contract before migrating: %s",
+			len(jailedKeys), strings.Join(jailedKeys, ", "))
+	}
+
+	numActive := len(m.keysInStatus(statuses, queries.StakingStatusStaked, queries.StakingStatusQueued))
+
+	return numActive == 0, fmt.Sprintf("%d/%d keys unstaked", len(m.state.BlsKeys)-numActive, len(m.state.BlsKeys)), nil
+}
+
+func (m *migration) sendLegacyUnStakeNodes() ([]string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	activeKeys := m.keysInStatus(statuses, queries.StakingStatusStaked, queries.StakingStatusQueued)
+
+	calls, err := m.legacyNodesCalls(operations.UnStakeNodes, activeKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.SendTransactions(m.proxy, m.skBytes, calls)
+}
+
+// checkUnBondPeriodPassed is done when all the unstaked keys can be unbonded
+func (m *migration) checkUnBondPeriodPassed() (bool, string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return false, "", err
+	}
+
+	maxRemaining := uint64(0)
+	for _, key := range m.keysInStatus(statuses, queries.StakingStatusUnStaked) {
+		decodedKey, _ := hex.DecodeString(key)
+		remaining, errQuery := queries.GetRemainingUnBondPeriod(m.proxy.(queries.VMQueryProxy), m.contract, decodedKey)
+		if errQuery != nil {
+			return false, "", errQuery
+		}
+		if remaining > maxRemaining {
+			maxRemaining = remaining
+		}
+	}
+
+	return maxRemaining == 0, fmt.Sprintf("%d rounds left", maxRemaining), nil
+}
+
+// checkLegacyNodesUnBonded is done when the staking SC no longer knows any of the keys
+func (m *migration) checkLegacyNodesUnBonded() (bool, string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return false, "", err
+	}
+
+	numUnBonded := len(m.keysInStatus(statuses, queries.StakingStatusNotRegistered))
+
+	return numUnBonded == len(m.state.BlsKeys), fmt.Sprintf("%d/%d keys unbonded", numUnBonded, len(m.state.BlsKeys)), nil
+}
+
+func (m *migration) sendLegacyUnBondNodes() ([]string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	unStakedKeys := m.keysInStatus(statuses, queries.StakingStatusUnStaked)
+
+	calls, err := m.legacyNodesCalls(operations.UnBondNodes, unStakedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.SendTransactions(m.proxy, m.skBytes, calls)
+}
+
+// checkOwnerStakeUnStaked is done when the owner has neither active nor waiting stake left in the legacy contract
+func (m *migration) checkOwnerStakeUnStaked() (bool, string, error) {
+	stake, err := m.ownerStake()
+	if err != nil {
+		return false, "", err
+	}
+
+	return stake.UnStakeable().Sign() == 0, fmt.Sprintf("active stake %s, waiting stake %s", stake.Active, stake.Waiting), nil
+}
+
+// sendOwnerUnStake unstakes the waiting and the active stake of the owner in a single call
+func (m *migration) sendOwnerUnStake() ([]string, error) {
+	stake, err := m.ownerStake()
+	if err != nil {
+		return nil, err
+	}
+
+	unStakeable := stake.UnStakeable()
+	if unStakeable.Sign() == 0 {
+		return nil, nil
+	}
+	m.state.OwnerUnStakedValue = unStakeable.String()
+
+	return common.SendTransactions(m.proxy, m.skBytes, []*common.TxCall{{
+		Receiver: m.contract,
+		Value:    big.NewInt(0),
+		GasLimit: ownerUnStakeGas,
+		Data:     "unStake@" + common.HexBigInt(unStakeable),
+	}})
+}
+
+// checkOwnerStakeWithdrawn is done when the owner has nothing left to unbond in the legacy contract and the unBond
+// transaction, if one was needed, succeeded
+func (m *migration) checkOwnerStakeWithdrawn() (bool, string, error) {
+	stake, err := m.ownerStake()
+	if err != nil {
+		return false, "", err
+	}
+	if stake.HasLockedFunds() {
+		return false, fmt.Sprintf("unbondable %s, withdraw only %s, unstaked %s", stake.UnBondable, stake.WithdrawOnly, stake.UnStaked), nil
+	}
+
+	progress := m.state.step(unBondOwnerStakeStep)
+	if len(progress.TxHashes) == 0 {
+		return true, "nothing to unbond", nil
+	}
+
+	succeeded, err := m.transactionsSucceeded(progress.TxHashes)
+	if err != nil {
+		return false, "", err
+	}
+	if !succeeded {
+		return false, "waiting for the unBond transaction", nil
+	}
+
+	return true, "withdrawn " + m.state.OwnerUnBondValue, nil
+}
+
+// sendOwnerUnBond sends nothing until all the unstaked funds can be unbonded, so a single unBond withdraws them
+func (m *migration) sendOwnerUnBond() ([]string, error) {
+	stake, err := m.ownerStake()
+	if err != nil {
+		return nil, err
+	}
+	locked := big.NewInt(0).Add(stake.WithdrawOnly, stake.UnStaked)
+	if stake.UnBondable.Sign() == 0 || stake.UnBondable.Cmp(locked) < 0 {
+		return nil, nil
+	}
+	m.state.OwnerUnBondValue = stake.UnBondable.String()
+
+	return common.SendTransactions(m.proxy, m.skBytes, []*common.TxCall{{
+		Receiver: m.contract,
+		Value:    big.NewInt(0),
+		GasLimit: ownerUnBondGas,
+		Data:     "unBond",
+	}})
+}
+
+// checkNodesStakedOnValidatorSC is done when all the keys are registered again in the staking SC
+func (m *migration) checkNodesStakedOnValidatorSC() (bool, string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return false, "", err
+	}
+
+	numStaked := len(m.keysInStatus(statuses, queries.StakingStatusStaked, queries.StakingStatusQueued, queries.StakingStatusJailed))
+
+	return numStaked == len(m.state.BlsKeys), fmt.Sprintf("%d/%d keys staked", numStaked, len(m.state.BlsKeys)), nil
+}
+
+// sendValidatorStake stakes the unregistered keys from the owner wallet, the proof of possession of each key being
+// its signature over the owner address
+func (m *migration) sendValidatorStake() ([]string, error) {
+	statuses, err := m.keyStatuses()
+	if err != nil {
+		return nil, err
+	}
+	keysToStake := m.keysInStatus(statuses, queries.StakingStatusNotRegistered)
+
+	nodePrice, err := queries.GetNodePrice(m.proxy.(queries.EpochStartProxy))
+	if err != nil {
+		return nil, err
+	}
+	totalValue := big.NewInt(0).Mul(nodePrice, big.NewInt(int64(len(keysToStake))))
+	balance, err := m.ownerBalance()
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(totalValue) < 0 {
+		return nil, fmt.Errorf("the owner balance %s is below the %s stake of %d keys", balance.String(), totalValue.String(), len(keysToStake))
+	}
+
+	blsPrivateKeys, blsPublicKeys, err := keys.LoadBlsKeys(m.blsKeysFile, m.passwords)
+	if err != nil {
+		return nil, err
+	}
+	defer keys.WipeAll(blsPrivateKeys)
+
+	privateKeys := make(map[string][]byte, len(blsPublicKeys))
+	for i, publicKey := range blsPublicKeys {
+		privateKeys[publicKey] = blsPrivateKeys[i]
+	}
+
+	validatorAddress, _ := data.NewAddressFromBytes(vm.ValidatorSCAddress).AddressAsBech32String()
+	calls := make([]*common.TxCall, 0)
+	for start := 0; start < len(keysToStake); start += maxKeysPerTx {
+		end := start + maxKeysPerTx
+		if end > len(keysToStake) {
+			end = len(keysToStake)
+		}
+
+		txData := fmt.Sprintf("stake@%x", big.NewInt(int64(end-start)).Bytes())
+		for _, publicKey := range keysToStake[start:end] {
+			privateKey, found := privateKeys[publicKey]
+			if !found {
+				return nil, fmt.Errorf("the private key of %s is not in %s", publicKey, m.blsKeysFile)
+			}
+
+			signature, errSign := keys.SignWithBlsKey(privateKey, m.ownerAddress.AddressBytes())
+			if errSign != nil {
+				return nil, fmt.Errorf("%w while signing with %s", errSign, publicKey)
+			}
+			txData += fmt.Sprintf("@%s@%x", publicKey, signature)
+		}
+
+		calls = append(calls, &common.TxCall{
+			Receiver: validatorAddress,
+			Value:    big.NewInt(0).Mul(nodePrice, big.NewInt(int64(end-start))),
+			GasLimit: baseStakeGas + stakeGasPerNode*uint64(end-start),
+			Data:     txData,
+		})
+	}
+
+	return common.SendTransactions(m.proxy, m.skBytes, calls)
+}
+
+// checkSystemDelegationCreated searches the newest delegation contracts for the one owned by the wallet that holds the
+// migrated keys
+func (m *migration) checkSystemDelegationCreated() (bool, string, error) {
+	if len(m.state.NewContract) > 0 {
+		return true, m.state.NewContract, nil
+	}
+
+	delegationManagerAddress, _ := data.NewAddressFromBytes(vm.DelegationManagerSCAddress).AddressAsBech32String()
+	returnData, err := queries.ExecuteVMQuery(m.proxy.(queries.VMQueryProxy), delegationManagerAddress, delegationManagerAddress, "getAllContractAddresses")
+	if err != nil {
+		return false, "", err
+	}
+
+	for i := len(returnData) - 1; i >= 0 && i >= len(returnData)-maxScannedContracts; i-- {
+		contract, errConvert := data.NewAddressFromBytes(returnData[i]).AddressAsBech32String()
+		if errConvert != nil {
+			continue
+		}
+
+		owner, errOwner := queries.GetContractOwner(m.proxy.(queries.ContractOwnerProxy), contract)
+		if errOwner != nil || !bytes.Equal(owner, m.ownerAddress.AddressBytes()) {
+			continue
+		}
+
+		holdsKeys, errStates := m.holdsMigratedKeys(contract)
+		if errStates != nil {
+			return false, "", errStates
+		}
+		if holdsKeys {
+			m.state.NewContract = contract
+			return true, contract, nil
+		}
+	}
+
+	return false, "contract not found", nil
+}
+
+func (m *migration) sendMakeNewContractFromValidatorData() ([]string, error) {
+	delegationManagerAddress, _ := data.NewAddressFromBytes(vm.DelegationManagerSCAddress).AddressAsBech32String()
+
+	return common.SendTransactions(m.proxy, m.skBytes, []*common.TxCall{{
+		Receiver: delegationManagerAddress,
+		Value:    big.NewInt(0),
+		GasLimit: makeContractGas,
+		Data:     fmt.Sprintf("makeNewContractFromValidatorData@%s@%s", common.HexBigInt(m.delegationCap), common.HexBigInt(m.serviceFee)),
+	}})
+}
+
+func (m *migration) holdsMigratedKeys(contract string) (bool, error) {
+	nodeStates, err := queries.GetAllNodeStates(m.proxy.(queries.VMQueryProxy), contract, contract)
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range m.state.BlsKeys {
+		_, found := nodeStates[key]
+		if !found {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// keyStatuses returns the staking SC status of every migrated key
+func (m *migration) keyStatuses() (map[string]string, error) {
+	statuses := make(map[string]string, len(m.state.BlsKeys))
+	for _, key := range m.state.BlsKeys {
+		decodedKey, err := hex.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w for BLS key %s", err, key)
+		}
+
+		status, err := queries.GetBLSKeyStatus(m.proxy.(queries.VMQueryProxy), m.contract, decodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w for BLS key %s", err, key)
+		}
+		statuses[key] = status
+	}
+
+	return statuses, nil
+}
+
+// keysInStatus returns, in the migration order, the keys having one of the provided statuses
+func (m *migration) keysInStatus(statuses map[string]string, wanted ...string) []string {
+	result := make([]string, 0)
+	for _, key := range m.state.BlsKeys {
+		for _, status := range wanted {
+			if statuses[key] == status {
+				result = append(result, key)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// legacyNodesCalls packs the keys in as few legacy contract calls as the gas and data size limits allow, using the
+// same gas model as the node operations tool
+func (m *migration) legacyNodesCalls(operationName string, blsKeys []string) ([]*common.TxCall, error) {
+	netConfigs, err := m.proxy.GetNetworkConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	model := operations.Supported[operationName].GasModels[operations.LegacyDelegation]
+	packs, err := operations.PackKeys(operationName, model, blsKeys, netConfigs.GasPerDataByte, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]*common.TxCall, 0, len(packs))
+	for _, pack := range packs {
+		calls = append(calls, &common.TxCall{
+			Receiver: m.contract,
+			Value:    big.NewInt(0),
+			GasLimit: model.GasLimit(len(pack)),
+			Data:     operationName + "@" + strings.Join(pack, "@"),
+		})
+	}
+
+	return calls, nil
+}
+
+// transactionsSucceeded returns true once all the transactions were executed successfully
+func (m *migration) transactionsSucceeded(hexTxHashes []string) (bool, error) {
+	for _, hash := range hexTxHashes {
+		status, err := m.proxy.(queries.TransactionStatusProxy).ProcessTransactionStatus(context.Background(), hash)
+		if err != nil {
+			return false, err
+		}
+		if status != transaction.TxStatusSuccess {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ownerStake returns the stake buckets of the owner in the legacy contract
+func (m *migration) ownerStake() (*queries.LegacyStake, error) {
+	return queries.GetLegacyStake(m.proxy.(queries.VMQueryProxy), m.contract, m.owner, m.ownerAddress.AddressBytes())
+}
+
+func (m *migration) ownerBalance() (*big.Int, error) {
+	account, err := m.proxy.GetAccount(context.Background(), m.ownerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, ok := big.NewInt(0).SetString(account.Balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %s for %s", account.Balance, m.owner)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	stepStatusPending = "pending"
+	stepStatusWaiting = "waiting"
+	stepStatusSent    = "sent"
+	stepStatusFailed  = "failed"
+	stepStatusDone    = "done"
+)
+
+// stepProgress is the persisted progress of a migration step
+type stepProgress struct {
+	Name         string   `json:"name"`
+	Status       string   `json:"status"`
+	Detail       string   `json:"detail,omitempty"`
+	TxHashes     []string `json:"txHashes,omitempty"`
+	FailedTxHash string   `json:"failedTxHash,omitempty"`
+	Attempts     int      `json:"attempts,omitempty"`
+	SentAt       string   `json:"sentAt,omitempty"`
+	StartedAt    string   `json:"startedAt,omitempty"`
+	CompletedAt  string   `json:"completedAt,omitempty"`
+}
+
+// migrationState is the persisted progress of the whole migration. The values are in the smallest denomination.
+type migrationState struct {
+	Contract           string          `json:"contract"`
+	Owner              string          `json:"owner"`
+	BlsKeys            []string        `json:"blsKeys"`
+	OwnerUnStakedValue string          `json:"ownerUnStakedValue,omitempty"`
+	OwnerUnBondValue   string          `json:"ownerUnBondValue,omitempty"`
+	NewContract        string          `json:"newContract,omitempty"`
+	Steps              []*stepProgress `json:"steps"`
+}
+
+func loadMigrationState(filename string) (*migrationState, error) {
+	state := &migrationState{
+		Steps: make([]*stepProgress, 0, len(migrationSteps)),
+	}
+
+	buff, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		err = json.Unmarshal(buff, state)
+		if err != nil {
+			return nil, fmt.Errorf("%w while reading %s", err, filename)
+		}
+	}
+
+	// steps added after the migration was started are appended as pending
+	for i := len(state.Steps); i < len(migrationSteps); i++ {
+		state.Steps = append(state.Steps, &stepProgress{
+			Name:   migrationSteps[i].name,
+			Status: stepStatusPending,
+		})
+	}
+
+	return state, nil
+}
+
+func (state *migrationState) printStatus() {
+	fmt.Println()
+	fmt.Printf("legacy contract: %s\n", valueOrDash(state.Contract))
+	fmt.Printf("owner:           %s\n", valueOrDash(state.Owner))
+	fmt.Printf("num keys:        %d\n", len(state.BlsKeys))
+	fmt.Printf("new contract:    %s\n", valueOrDash(state.NewContract))
+	fmt.Println()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "#\tSTEP\tSTATUS\tATTEMPTS\tSTARTED\tCOMPLETED\tDETAIL")
+	for i, progress := range state.Steps {
+		_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			i+1, progress.Name, progress.Status, progress.Attempts,
+			valueOrDash(progress.StartedAt), valueOrDash(progress.CompletedAt), valueOrDash(progress.Detail))
+	}
+	_ = writer.Flush()
+
+	for _, progress := range state.Steps {
+		if len(progress.TxHashes) > 0 {
+			fmt.Printf("%s transactions: %s\n", progress.Name, strings.Join(progress.TxHashes, ", "))
+		}
+	}
+	fmt.Println()
+}
+
+// step returns the progress of the named step
+func (state *migrationState) step(name string) *stepProgress {
+	for _, progress := range state.Steps {
+		if progress.Name == name {
+			return progress
+		}
+	}
+
+	return &stepProgress{Name: name}
+}
+
+func (progress *stepProgress) start() {
+	if len(progress.StartedAt) == 0 {
+		progress.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+func (progress *stepProgress) complete(detail string) {
+	progress.start()
+	progress.Status = stepStatusDone
+	progress.Detail = detail
+	progress.CompletedAt = time.Now().UTC().Format(time.RFC3339)
+}
+
+func valueOrDash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+
+	return value
+}
@@ -0,0 +1,155 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+
+	"v1/queries"
+)
+
+const (
+	StakeNodes           = "stakeNodes"
+	UnStakeNodes         = "unStakeNodes"
+	UnBondNodes          = "unBondNodes"
+	ReStakeUnStakedNodes = "reStakeUnStakedNodes"
+	RemoveNodes          = "removeNodes"
+)
+
+// ContractKind tells the legacy delegation contract from the system delegation contracts
+type ContractKind string
+
+const (
+	LegacyDelegation ContractKind = "legacy"
+	SystemDelegation ContractKind = "system"
+)
+
+// MaxGasPerTransaction is the max gas limit a transaction is accepted with
+const MaxGasPerTransaction = 600000000
+
+// MaxTransactionDataSize is the max size of the data field accepted by the network
+const MaxTransactionDataSize = 256 * 1024
+
+// legacyGasPerKey is the marginal cost of a key in a legacy contract node call. The validator system SC charges 5
+// million for every key of a stake, unStake, unBond or reStakeUnStakedNodes call, the Stake, UnStake & UnBond entries of
+// the MetaChainSystemSCsCost gas schedule. On top of it, the contract writes the node state of the key before the asynchronous call and again in its callback, about 1 million
+// each at 10000 gas per stored byte, and forwards the hex key in the call data, 193 bytes at 1500 gas per byte. The
+// 7.3 million total is rounded up for the contract bookkeeping.
+const legacyGasPerKey = 10000000
+
+// the single key legacy unStakeNodes and unBondNodes were proven to need 300 and 100 million gas, the fixed part of the
+// asynchronous call is what is left once the key cost is taken out
+const legacySingleKeyStakingCallGas = 300000000
+const legacySingleKeyUnBondCallGas = 100000000
+
+// GasModel is the gas needed by a node operation: a base cost for the call plus a cost for every key in the
+// transaction, the data field gas being added on top
+type GasModel struct {
+	BaseGas   uint64
+	GasPerKey uint64
+}
+
+// GasLimit returns the gas needed for numKeys keys, the data field gas excluded
+func (model GasModel) GasLimit(numKeys int) uint64 {
+	return model.BaseGas + model.GasPerKey*uint64(numKeys)
+}
+
+// NodeOperation is a delegation contract endpoint taking a list of BLS keys. RequiredState is the contract node state
+// and StakingStatuses the staking SC statuses a key must have for the operation.
+type NodeOperation struct {
+	Name            string
+	RequiredState   string
+	StakingStatuses []string
+	GasModels       map[ContractKind]GasModel
+}
+
+// Supported holds the supported endpoints. The legacy contract forwards the keys to the staking system SCs in an
+// asynchronous call so it needs a lot more gas than the system delegation contract.
+var Supported = map[string]*NodeOperation{
+	StakeNodes: {
+		Name:            StakeNodes,
+		RequiredState:   queries.NodeStateNotStaked,
+		StakingStatuses: []string{queries.StakingStatusNotRegistered},
+		GasModels: map[ContractKind]GasModel{
+			LegacyDelegation: {BaseGas: legacySingleKeyStakingCallGas - legacyGasPerKey, GasPerKey: legacyGasPerKey},
+			SystemDelegation: {BaseGas: 50000000, GasPerKey: 6000000},
+		},
+	},
+	UnStakeNodes: {
+		Name:            UnStakeNodes,
+		RequiredState:   queries.NodeStateStaked,
+		StakingStatuses: []string{queries.StakingStatusStaked, queries.StakingStatusQueued},
+		GasModels: map[ContractKind]GasModel{
+			LegacyDelegation: {BaseGas: legacySingleKeyStakingCallGas - legacyGasPerKey, GasPerKey: legacyGasPerKey},
+			SystemDelegation: {BaseGas: 30000000, GasPerKey: 6000000},
+		},
+	},
+	UnBondNodes: {
+		Name:            UnBondNodes,
+		RequiredState:   queries.NodeStateUnStaked,
+		StakingStatuses: []string{queries.StakingStatusUnStaked},
+		GasModels: map[ContractKind]GasModel{
+			LegacyDelegation: {BaseGas: legacySingleKeyUnBondCallGas - legacyGasPerKey, GasPerKey: legacyGasPerKey},
+			SystemDelegation: {BaseGas: 30000000, GasPerKey: 6000000},
+		},
+	},
+	ReStakeUnStakedNodes: {
+		Name:            ReStakeUnStakedNodes,
+		RequiredState:   queries.NodeStateUnStaked,
+		StakingStatuses: []string{queries.StakingStatusUnStaked},
+		GasModels: map[ContractKind]GasModel{
+			LegacyDelegation: {BaseGas: legacySingleKeyStakingCallGas - legacyGasPerKey, GasPerKey: legacyGasPerKey},
+			SystemDelegation: {BaseGas: 30000000, GasPerKey: 6000000},
+		},
+	},
+	RemoveNodes: {
+		Name:            RemoveNodes,
+		RequiredState:   queries.NodeStateNotStaked,
+		StakingStatuses: []string{queries.StakingStatusNotRegistered},
+		GasModels: map[ContractKind]GasModel{
+			LegacyDelegation: {BaseGas: 10000000, GasPerKey: 5000000},
+			SystemDelegation: {BaseGas: 10000000, GasPerKey: 1500000},
+		},
+	},
+}
+
+// Available returns the sorted names of the supported operations
+func Available() []string {
+	names := make([]string, 0, len(Supported))
+	for name := range Supported {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// PackKeys splits the keys in as few transactions as possible, each one staying under the max gas limit and the max
+// data size. A positive maxKeysPerTx also caps the number of keys in a transaction.
+func PackKeys(operationName string, model GasModel, blsKeys []string, gasPerDataByte uint64, maxKeysPerTx int) ([][]string, error) {
+	packs := make([][]string, 0)
+	current := make([]string, 0)
+	dataSize := len(operationName)
+	for _, blsKey := range blsKeys {
+		keyDataSize := len("@") + len(blsKey)
+		fits := len(current) > 0 &&
+			(maxKeysPerTx <= 0 || len(current) < maxKeysPerTx) &&
+			dataSize+keyDataSize <= MaxTransactionDataSize &&
+			model.GasLimit(len(current)+1)+gasPerDataByte*uint64(dataSize+keyDataSize) <= MaxGasPerTransaction
+		if len(current) > 0 && !fits {
+			packs = append(packs, current)
+			current = make([]string, 0)
+			dataSize = len(operationName)
+		}
+
+		dataSize += keyDataSize
+		if model.GasLimit(1)+gasPerDataByte*uint64(dataSize) > MaxGasPerTransaction {
+			return nil, fmt.Errorf("%s does not fit a single key in a transaction", operationName)
+		}
+		current = append(current, blsKey)
+	}
+	if len(current) > 0 {
+		packs = append(packs, current)
+	}
+
+	return packs, nil
+}
@@ -0,0 +1,130 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testGasPerDataByte = 1500
+
+func createKeys(numKeys int, keyLength int) []string {
+	blsKeys := make([]string, 0, numKeys)
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("%04d", i)
+		blsKeys = append(blsKeys, key+strings.Repeat("a", keyLength-len(key)))
+	}
+
+	return blsKeys
+}
+
+func TestPackKeys(t *testing.T) {
+	keyLength := 192
+	tests := []struct {
+		name           string
+		operation      string
+		model          GasModel
+		blsKeys        []string
+		gasPerDataByte uint64
+		maxKeysPerTx   int
+		expectedSizes  []int
+	}{
+		{
+			name:           "no keys",
+			operation:      UnStakeNodes,
+			model:          Supported[UnStakeNodes].GasModels[SystemDelegation],
+			blsKeys:        nil,
+			gasPerDataByte: testGasPerDataByte,
+			expectedSizes:  []int{},
+		},
+		{
+			name:           "all the keys fit in one system delegation transaction",
+			operation:      UnStakeNodes,
+			model:          Supported[UnStakeNodes].GasModels[SystemDelegation],
+			blsKeys:        createKeys(50, keyLength),
+			gasPerDataByte: testGasPerDataByte,
+			expectedSizes:  []int{50},
+		},
+		{
+			name:           "the keys per transaction cap",
+			operation:      UnStakeNodes,
+			model:          Supported[UnStakeNodes].GasModels[SystemDelegation],
+			blsKeys:        createKeys(5, keyLength),
+			gasPerDataByte: testGasPerDataByte,
+			maxKeysPerTx:   2,
+			expectedSizes:  []int{2, 2, 1},
+		},
+		{
+			name:           "legacy unStakeNodes",
+			operation:      UnStakeNodes,
+			model:          Supported[UnStakeNodes].GasModels[LegacyDelegation],
+			blsKeys:        createKeys(100, keyLength),
+			gasPerDataByte: testGasPerDataByte,
+			expectedSizes:  []int{30, 30, 30, 10},
+		},
+		{
+			name:           "legacy unBondNodes",
+			operation:      UnBondNodes,
+			model:          Supported[UnBondNodes].GasModels[LegacyDelegation],
+			blsKeys:        createKeys(100, keyLength),
+			gasPerDataByte: testGasPerDataByte,
+			expectedSizes:  []int{49, 49, 2},
+		},
+		{
+			name:           "the data gas counts against the gas limit",
+			operation:      UnStakeNodes,
+			model:          GasModel{},
+			blsKeys:        createKeys(3, keyLength),
+			gasPerDataByte: MaxGasPerTransaction / 400,
+			expectedSizes:  []int{2, 1},
+		},
+		{
+			name:           "the data size limit",
+			operation:      UnStakeNodes,
+			model:          GasModel{},
+			blsKeys:        createKeys(5, 100000),
+			gasPerDataByte: 0,
+			expectedSizes:  []int{2, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packs, err := PackKeys(tt.operation, tt.model, tt.blsKeys, tt.gasPerDataByte, tt.maxKeysPerTx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sizes := make([]int, 0, len(packs))
+			packed := make([]string, 0, len(tt.blsKeys))
+			for _, pack := range packs {
+				sizes = append(sizes, len(pack))
+				packed = append(packed, pack...)
+
+				txData := tt.operation + "@" + strings.Join(pack, "@")
+				if len(txData) > MaxTransactionDataSize {
+					t.Fatalf("data size %d above the limit", len(txData))
+				}
+				gasLimit := tt.model.GasLimit(len(pack)) + tt.gasPerDataByte*uint64(len(txData))
+				if gasLimit > MaxGasPerTransaction {
+					t.Fatalf("gas limit %d above the limit", gasLimit)
+				}
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.expectedSizes) {
+				t.Fatalf("got packs of %v keys, expected %v", sizes, tt.expectedSizes)
+			}
+			if strings.Join(packed, ",") != strings.Join(tt.blsKeys, ",") {
+				t.Fatal("the keys were not packed in order")
+			}
+		})
+	}
+}
+
+func TestPackKeysSingleKeyDoesNotFit(t *testing.T) {
+	model := GasModel{BaseGas: MaxGasPerTransaction, GasPerKey: 1}
+
+	_, err := PackKeys(UnStakeNodes, model, createKeys(1, 192), testGasPerDataByte, 0)
+	if err == nil {
+		t.Fatal("expected an error for a key that does not fit in a transaction")
+	}
+}
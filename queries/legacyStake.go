@@ -0,0 +1,75 @@
+package queries
+
+import (
+	"math/big"
+)
+
+// LegacyStake is what a delegator holds in a legacy delegation contract, in the smallest denomination
+type LegacyStake struct {
+	WithdrawOnly    *big.Int
+	Waiting         *big.Int
+	Active          *big.Int
+	UnStaked        *big.Int
+	DeferredPayment *big.Int
+	UnBondable      *big.Int
+}
+
+// GetLegacyStake reads the delegator stake buckets from getUserStakeByType, which returns the withdraw only, waiting,
+// active, unstaked and deferred payment stake in this order, and the value an unBond call returns from getUnBondable
+func GetLegacyStake(proxy VMQueryProxy, contract string, caller string, delegator []byte) (*LegacyStake, error) {
+	stakeByType, err := queryBigInts(proxy, contract, caller, "getUserStakeByType", 5, delegator)
+	if err != nil {
+		return nil, err
+	}
+	unBondable, err := queryBigInts(proxy, contract, caller, "getUnBondable", 1, delegator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LegacyStake{
+		WithdrawOnly:    stakeByType[0],
+		Waiting:         stakeByType[1],
+		Active:          stakeByType[2],
+		UnStaked:        stakeByType[3],
+		DeferredPayment: stakeByType[4],
+		UnBondable:      unBondable[0],
+	}, nil
+}
+
+// UnStakeable returns the value an unStake call takes out of the contract. The legacy unStake drains the waiting stake
+// first, moving it to the withdraw only bucket, then the active stake. The waiting stake is included as a contract being
+// wound down never activates it.
+func (stake *LegacyStake) UnStakeable() *big.Int {
+	return big.NewInt(0).Add(stake.Waiting, stake.Active)
+}
+
+// HasLockedFunds returns true if an unBond call will eventually return some of the funds
+func (stake *LegacyStake) HasLockedFunds() bool {
+	return stake.UnBondable.Sign() > 0 ||
+		stake.WithdrawOnly.Sign() > 0 ||
+		stake.UnStaked.Sign() > 0 ||
+		stake.DeferredPayment.Sign() > 0
+}
+
+// IsEmpty returns true if the delegator has nothing left in the contract
+func (stake *LegacyStake) IsEmpty() bool {
+	return stake.UnStakeable().Sign() == 0 && !stake.HasLockedFunds()
+}
+
+// queryBigInts runs the view function and returns its first numValues results, the missing ones being 0
+func queryBigInts(proxy VMQueryProxy, contract string, caller string, funcName string, numValues int, args ...[]byte) ([]*big.Int, error) {
+	returnData, err := ExecuteVMQuery(proxy, contract, caller, funcName, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*big.Int, numValues)
+	for i := range values {
+		values[i] = big.NewInt(0)
+		if i < len(returnData) {
+			values[i].SetBytes(returnData[i])
+		}
+	}
+
+	return values, nil
+}
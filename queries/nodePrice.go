@@ -0,0 +1,65 @@
+package queries
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/multiversx/mx-chain-core-go/core"
+	"github.com/multiversx/mx-chain-core-go/data/api"
+)
+
+const metaBlockByNonceEndpoint = "block/%d/by-nonce/%d"
+
+// EpochStartProxy is the proxy part needed to read the start of epoch meta block
+type EpochStartProxy interface {
+	GetNonceAtEpochStart(ctx context.Context, shardId uint32) (uint64, error)
+	GetHTTP(ctx context.Context, endpoint string) ([]byte, int, error)
+}
+
+type metaBlockResponse struct {
+	Data struct {
+		Block *api.Block `json:"block"`
+	} `json:"data"`
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// GetNodePrice fetches the node price from the economics section of the current epoch's start of epoch meta block.
+// None of the validator system SC view functions expose the node price, so this is the only on-chain source for it.
+func GetNodePrice(proxy EpochStartProxy) (*big.Int, error) {
+	nonce, err := proxy.GetNonceAtEpochStart(context.Background(), core.MetachainShardId)
+	if err != nil {
+		return nil, err
+	}
+
+	buff, code, err := proxy.GetHTTP(context.Background(), fmt.Sprintf(metaBlockByNonceEndpoint, core.MetachainShardId, nonce))
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("error fetching start of epoch meta block %d, HTTP status code %d", nonce, code)
+	}
+
+	response := &metaBlockResponse{}
+	err = json.Unmarshal(buff, response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	if response.Data.Block == nil || response.Data.Block.EpochStartInfo == nil {
+		return nil, fmt.Errorf("meta block %d does not contain the start of epoch info", nonce)
+	}
+
+	nodePrice, ok := big.NewInt(0).SetString(response.Data.Block.EpochStartInfo.NodePrice, 10)
+	if !ok || nodePrice.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid node price %s in meta block %d", response.Data.Block.EpochStartInfo.NodePrice, nonce)
+	}
+
+	return nodePrice, nil
+}
@@ -0,0 +1,99 @@
+package queries
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+// The node states of a delegation contract, the legacy contract states being mapped onto the system contract ones
+const (
+	NodeStateStaked    = "staked"
+	NodeStateNotStaked = "notStaked"
+	NodeStateUnStaked  = "unStaked"
+	NodeStatePending   = "pending"
+	NodeStateRemoved   = "removed"
+	NodeStateFailed    = "activationFailed"
+	NodeStateUnknown   = "unknown"
+)
+
+// BlsKeyLength is the length of a BLS public key, in bytes
+const BlsKeyLength = 96
+
+// legacyNodeStates maps the legacy delegation contract NodeState enum discriminants to the system delegation states
+var legacyNodeStates = map[byte]string{
+	0: NodeStateNotStaked, // Inactive
+	1: NodeStatePending,   // PendingActivation
+	2: NodeStateStaked,    // Active
+	3: NodeStatePending,   // PendingDeactivation
+	4: NodeStateUnStaked,  // UnBondPeriod
+	5: NodeStatePending,   // PendingUnBond
+	6: NodeStateRemoved,   // Removed
+	7: NodeStateFailed,    // ActivationFailed
+}
+
+// GetAllNodeStates returns the state of every key added in the delegation contract, keyed by the hex encoded BLS key.
+// Both the system and the legacy delegation contracts are supported.
+func GetAllNodeStates(proxy VMQueryProxy, contract string, caller string) (map[string]string, error) {
+	address, err := data.NewAddressFromBech32String(contract)
+	if err != nil {
+		return nil, fmt.Errorf("%w for contract %s", err, contract)
+	}
+
+	returnData, err := ExecuteVMQuery(proxy, contract, caller, "getAllNodeStates")
+	if err != nil {
+		return nil, err
+	}
+
+	if IsSystemDelegationContract(address.AddressBytes()) {
+		return parseSystemNodeStates(returnData), nil
+	}
+
+	return parseLegacyNodeStates(returnData), nil
+}
+
+// parseSystemNodeStates decodes a list made of a state label followed by the keys in that state
+func parseSystemNodeStates(returnData [][]byte) map[string]string {
+	states := make(map[string]string)
+	currentState := NodeStateUnknown
+	for _, item := range returnData {
+		if len(item) != BlsKeyLength {
+			currentState = string(item)
+			continue
+		}
+
+		states[hex.EncodeToString(item)] = currentState
+	}
+
+	return states
+}
+
+// parseLegacyNodeStates decodes a list of (key, state) pairs. The state is an enum whose first byte is the
+// discriminant, the zero discriminant being encoded as an empty item.
+func parseLegacyNodeStates(returnData [][]byte) map[string]string {
+	states := make(map[string]string)
+	currentKey := ""
+	for _, item := range returnData {
+		if len(item) == BlsKeyLength {
+			currentKey = hex.EncodeToString(item)
+			states[currentKey] = NodeStateUnknown
+			continue
+		}
+		if len(currentKey) == 0 {
+			continue
+		}
+
+		discriminant := byte(0)
+		if len(item) > 0 {
+			discriminant = item[0]
+		}
+		state, found := legacyNodeStates[discriminant]
+		if !found {
+			state = NodeStateUnknown
+		}
+		states[currentKey] = state
+	}
+
+	return states
+}
@@ -0,0 +1,143 @@
+package queries
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/data/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+const legacyContract = "erd1qqqqqqqqqqqqqpgq97wezxw6l7lgg7k9rxvycrz66vn92ksh2tssxwf7ep"
+
+// vmQueryStub returns the same data for every query and keeps the last request
+type vmQueryStub struct {
+	returnData  [][]byte
+	lastRequest *data.VmValueRequest
+}
+
+func (stub *vmQueryStub) ExecuteVMQuery(_ context.Context, vmRequest *data.VmValueRequest) (*data.VmValuesResponseData, error) {
+	stub.lastRequest = vmRequest
+
+	return &data.VmValuesResponseData{
+		Data: &vm.VMOutputApi{
+			ReturnCode: ReturnCodeOk,
+			ReturnData: stub.returnData,
+		},
+	}, nil
+}
+
+func blsKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, BlsKeyLength)
+}
+
+func hexBlsKey(b byte) string {
+	return hex.EncodeToString(blsKey(b))
+}
+
+func systemDelegationContract(t *testing.T) string {
+	addressBytes := make([]byte, 32)
+	addressBytes[9] = 1
+	addressBytes[31] = 0x2a
+
+	contract, err := data.NewAddressFromBytes(addressBytes).AddressAsBech32String()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return contract
+}
+
+func TestLegacyContractNodeStates(t *testing.T) {
+	// the legacy contract returns (key, state) pairs, an Inactive key coming with an empty state item
+	proxy := &vmQueryStub{
+		returnData: [][]byte{
+			blsKey(1), {},
+			blsKey(2), {1},
+			blsKey(3), {2},
+			blsKey(4), {3},
+			blsKey(5), {4},
+			blsKey(6), {5},
+			blsKey(7), {6},
+			blsKey(8), {7},
+			blsKey(9), {42},
+		},
+	}
+
+	states, err := GetAllNodeStates(proxy, legacyContract, "caller")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		hexBlsKey(1): NodeStateNotStaked,
+		hexBlsKey(2): NodeStatePending,
+		hexBlsKey(3): NodeStateStaked,
+		hexBlsKey(4): NodeStatePending,
+		hexBlsKey(5): NodeStateUnStaked,
+		hexBlsKey(6): NodeStatePending,
+		hexBlsKey(7): NodeStateRemoved,
+		hexBlsKey(8): NodeStateFailed,
+		hexBlsKey(9): NodeStateUnknown,
+	}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("got %v, expected %v", states, expected)
+	}
+	if proxy.lastRequest.FuncName != "getAllNodeStates" || proxy.lastRequest.Address != legacyContract {
+		t.Errorf("unexpected query %s on %s", proxy.lastRequest.FuncName, proxy.lastRequest.Address)
+	}
+}
+
+func TestLegacyNodeStatesWithMissingItems(t *testing.T) {
+	// a state before the first key is dropped and a key followed by another key has no known state
+	states := parseLegacyNodeStates([][]byte{{2}, blsKey(1), blsKey(2), {4}})
+
+	expected := map[string]string{
+		hexBlsKey(1): NodeStateUnknown,
+		hexBlsKey(2): NodeStateUnStaked,
+	}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("got %v, expected %v", states, expected)
+	}
+}
+
+func TestSystemContractNodeStates(t *testing.T) {
+	// the system contract returns every state label followed by the keys in that state. The same data read as a legacy
+	// contract answer would yield unknown states, so this also checks the contract kind detection.
+	proxy := &vmQueryStub{
+		returnData: [][]byte{
+			[]byte(NodeStateStaked), blsKey(1), blsKey(2),
+			[]byte(NodeStateNotStaked),
+			[]byte(NodeStateUnStaked), blsKey(3),
+		},
+	}
+
+	states, err := GetAllNodeStates(proxy, systemDelegationContract(t), "caller")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		hexBlsKey(1): NodeStateStaked,
+		hexBlsKey(2): NodeStateStaked,
+		hexBlsKey(3): NodeStateUnStaked,
+	}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("got %v, expected %v", states, expected)
+	}
+}
+
+func TestNodeStatesOfAnInvalidContract(t *testing.T) {
+	proxy := &vmQueryStub{}
+
+	_, err := GetAllNodeStates(proxy, "not-an-address", "caller")
+	if err == nil {
+		t.Fatal("expected an error for an invalid contract address")
+	}
+	if proxy.lastRequest != nil {
+		t.Error("no query should be sent for an invalid contract address")
+	}
+}
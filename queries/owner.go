@@ -0,0 +1,73 @@
+package queries
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+// ContractOwnerProxy is the proxy part needed to read the owner of a contract
+type ContractOwnerProxy interface {
+	VMQueryProxy
+	GetAccount(ctx context.Context, address core.AddressHandler) (*data.Account, error)
+}
+
+// IsSystemDelegationContract returns true for the delegation contracts deployed by the delegation manager in the
+// metachain, as opposed to the contracts deployed in a shard
+func IsSystemDelegationContract(contractAddress []byte) bool {
+	return len(contractAddress) > 10 && contractAddress[8] == 0 && contractAddress[9] == 1
+}
+
+// GetContractOwner returns the owner of the contract. The system delegation contracts are owned by the SC owner
+// address written in their config, the other contracts by the account that deployed them.
+func GetContractOwner(proxy ContractOwnerProxy, contract string) ([]byte, error) {
+	address, err := data.NewAddressFromBech32String(contract)
+	if err != nil {
+		return nil, fmt.Errorf("%w for contract %s", err, contract)
+	}
+
+	if IsSystemDelegationContract(address.AddressBytes()) {
+		returnData, errQuery := ExecuteVMQuery(proxy, contract, contract, "getContractConfig")
+		if errQuery != nil {
+			return nil, errQuery
+		}
+		if len(returnData) == 0 {
+			return nil, fmt.Errorf("empty getContractConfig response for %s", contract)
+		}
+
+		return returnData[0], nil
+	}
+
+	account, err := proxy.GetAccount(context.Background(), address)
+	if err != nil {
+		return nil, err
+	}
+	if len(account.OwnerAddress) == 0 {
+		return nil, fmt.Errorf("%s has no owner, it is not a deployed contract", contract)
+	}
+	owner, err := data.NewAddressFromBech32String(account.OwnerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the owner of %s", err, contract)
+	}
+
+	return owner.AddressBytes(), nil
+}
+
+// CheckContractOwner returns an error naming both addresses if the signer does not own the contract
+func CheckContractOwner(proxy ContractOwnerProxy, contract string, signer core.AddressHandler) error {
+	owner, err := GetContractOwner(proxy, contract)
+	if err != nil {
+		return fmt.Errorf("%w while reading the owner of %s", err, contract)
+	}
+	if bytes.Equal(owner, signer.AddressBytes()) {
+		return nil
+	}
+
+	signerAddress, _ := signer.AddressAsBech32String()
+	ownerAddress, _ := data.NewAddressFromBytes(owner).AddressAsBech32String()
+
+	return fmt.Errorf("%s is not the owner of contract %s, the owner is %s", signerAddress, contract, ownerAddress)
+}
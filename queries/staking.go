@@ -0,0 +1,53 @@
+package queries
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/multiversx/mx-chain-go/vm"
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+// The statuses of a BLS key in the staking system SC
+const (
+	StakingStatusStaked        = "staked"
+	StakingStatusJailed        = "jailed"
+	StakingStatusQueued        = "queued"
+	StakingStatusUnStaked      = "unStaked"
+	StakingStatusNotRegistered = "notRegistered"
+)
+
+const blsKeyNotRegisteredMessage = "not registered in staking sc"
+
+// GetBLSKeyStatus returns the status of the key in the staking SC, StakingStatusNotRegistered for the keys the staking
+// SC does not know about
+func GetBLSKeyStatus(proxy VMQueryProxy, caller string, blsKey []byte) (string, error) {
+	stakingAddress, _ := data.NewAddressFromBytes(vm.StakingSCAddress).AddressAsBech32String()
+	returnData, err := ExecuteVMQuery(proxy, stakingAddress, caller, "getBLSKeyStatus", blsKey)
+	if err != nil && strings.Contains(err.Error(), blsKeyNotRegisteredMessage) {
+		return StakingStatusNotRegistered, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(returnData) == 0 {
+		return "", fmt.Errorf("empty getBLSKeyStatus response for %x", blsKey)
+	}
+
+	return string(returnData[0]), nil
+}
+
+// GetRemainingUnBondPeriod returns the number of rounds left until the unstaked key can be unbonded
+func GetRemainingUnBondPeriod(proxy VMQueryProxy, caller string, blsKey []byte) (uint64, error) {
+	stakingAddress, _ := data.NewAddressFromBytes(vm.StakingSCAddress).AddressAsBech32String()
+	returnData, err := ExecuteVMQuery(proxy, stakingAddress, caller, "getRemainingUnBondPeriod", blsKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(returnData) == 0 {
+		return 0, nil
+	}
+
+	return big.NewInt(0).SetBytes(returnData[0]).Uint64(), nil
+}
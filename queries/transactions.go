@@ -0,0 +1,37 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+)
+
+// TransactionDeadline is how long a sent transaction the proxy does not know the status of, as one dropped from the
+// mempool, is waited for before it is considered failed
+const TransactionDeadline = 30 * time.Minute
+
+// TransactionStatusProxy is the proxy part needed to read the processed status of a transaction
+type TransactionStatusProxy interface {
+	ProcessTransactionStatus(ctx context.Context, hexTxHash string) (transaction.TxStatus, error)
+}
+
+// FindFailedTransaction returns the first of the transactions sent at sentAt that did not succeed, empty if all of
+// them succeeded or are still pending. A transaction whose status can not be read is returned as failed once the
+// TransactionDeadline passed, the error being returned before that.
+func FindFailedTransaction(proxy TransactionStatusProxy, hexTxHashes []string, sentAt time.Time) (string, error) {
+	for _, hash := range hexTxHashes {
+		status, err := proxy.ProcessTransactionStatus(context.Background(), hash)
+		if err != nil && time.Since(sentAt) > TransactionDeadline {
+			return hash, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if status != transaction.TxStatusSuccess && status != transaction.TxStatusPending {
+			return hash, nil
+		}
+	}
+
+	return "", nil
+}
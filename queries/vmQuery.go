@@ -0,0 +1,46 @@
+package queries
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/multiversx/mx-sdk-go/data"
+)
+
+// ReturnCodeOk is the VM query return code for a successful execution
+const ReturnCodeOk = "ok"
+
+// VMQueryProxy is the proxy part needed to run view functions
+type VMQueryProxy interface {
+	ExecuteVMQuery(ctx context.Context, vmRequest *data.VmValueRequest) (*data.VmValuesResponseData, error)
+}
+
+// ExecuteVMQuery runs a view function on the provided contract and returns the raw return data. A return code other
+// than ok is returned as an error holding the return message.
+func ExecuteVMQuery(proxy VMQueryProxy, scAddress string, caller string, funcName string, args ...[]byte) ([][]byte, error) {
+	hexArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		hexArgs = append(hexArgs, hex.EncodeToString(arg))
+	}
+
+	request := &data.VmValueRequest{
+		Address:    scAddress,
+		FuncName:   funcName,
+		CallerAddr: caller,
+		Args:       hexArgs,
+	}
+
+	response, err := proxy.ExecuteVMQuery(context.Background(), request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Data == nil {
+		return nil, fmt.Errorf("empty response for %s on %s", funcName, scAddress)
+	}
+	if response.Data.ReturnCode != ReturnCodeOk {
+		return nil, fmt.Errorf("%s on %s returned %s: %s", funcName, scAddress, response.Data.ReturnCode, response.Data.ReturnMessage)
+	}
+
+	return response.Data.ReturnData, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/keys"
+	"v1/queries"
+)
+
+const allNodeStates = "all"
+
+// keySources holds the places the BLS keys can be read from, all the provided sources are merged
+type keySources struct {
+	list      string
+	listFile  string
+	pemFile   string
+	fromState string
+}
+
+func (sources keySources) isEmpty() bool {
+	return len(sources.list) == 0 && len(sources.listFile) == 0 && len(sources.pemFile) == 0 && len(sources.fromState) == 0
+}
+
+// resolveKeys reads the keys from all the provided sources, dropping the duplicates and keeping the first seen order
+func resolveKeys(
+	proxy interactors.Proxy,
+	contract string,
+	caller string,
+	sources keySources,
+	passwords *keys.PasswordProvider,
+) ([]string, error) {
+	if sources.isEmpty() {
+		return nil, errors.New("no BLS keys source provided, use -keys, -keys-file, -keys-pem or -keys-from-state")
+	}
+
+	resolved := make([]string, 0)
+	if len(sources.list) > 0 {
+		resolved = append(resolved, strings.Split(sources.list, ",")...)
+	}
+	if len(sources.listFile) > 0 {
+		fileKeys, err := readKeysListFile(sources.listFile)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, fileKeys...)
+	}
+	if len(sources.pemFile) > 0 {
+		privateKeys, publicKeys, err := keys.LoadBlsKeys(sources.pemFile, passwords)
+		if err != nil {
+			return nil, fmt.Errorf("%w while loading %s", err, sources.pemFile)
+		}
+		// only the public keys are needed
+		keys.WipeAll(privateKeys)
+		resolved = append(resolved, publicKeys...)
+	}
+	if len(sources.fromState) > 0 {
+		stateKeys, err := readKeysInState(proxy, contract, caller, sources.fromState)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, stateKeys...)
+	}
+
+	return uniqueKeys(resolved), nil
+}
+
+// readKeysListFile reads a text file holding one key per line or a CSV file holding the key in its first column.
+// Empty lines, lines starting with # and a non hex header are skipped.
+func readKeysListFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	fileKeys := make([]string, 0)
+	for {
+		record, errRead := reader.Read()
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return nil, fmt.Errorf("%w while reading %s", errRead, filename)
+		}
+
+		key := strings.TrimSpace(record[0])
+		if len(key) == 0 {
+			continue
+		}
+		if len(key) != queries.BlsKeyLength*2 {
+			if len(fileKeys) == 0 {
+				// header line
+				continue
+			}
+			return nil, fmt.Errorf("invalid BLS key %s in %s", key, filename)
+		}
+
+		fileKeys = append(fileKeys, key)
+	}
+
+	return fileKeys, nil
+}
+
+// readKeysInState returns the contract keys in the provided state, or all of them for the "all" state
+func readKeysInState(proxy interactors.Proxy, contract string, caller string, state string) ([]string, error) {
+	states, err := getNodeStates(proxy, contract, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	stateKeys := make([]string, 0, len(states))
+	for key, keyState := range states {
+		if state == allNodeStates || keyState == state {
+			stateKeys = append(stateKeys, key)
+		}
+	}
+	sort.Strings(stateKeys)
+
+	return stateKeys, nil
+}
+
+func uniqueKeys(blsKeys []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(blsKeys))
+	for _, key := range blsKeys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if len(key) == 0 || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		unique = append(unique, key)
+	}
+
+	return unique
+}
+
+// confirmKeys prints the keys the operation will be sent for and waits for the user's confirmation
+func confirmKeys(operationName string, contract string, blsKeys []string) bool {
+	fmt.Printf("%s will be sent to %s for %d key(s):\n", operationName, contract, len(blsKeys))
+	for i, key := range blsKeys {
+		fmt.Printf("%4d. %s\n", i+1, key)
+	}
+	fmt.Print("continue? [y/N]: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
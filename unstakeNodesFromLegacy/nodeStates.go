@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	chainCore "github.com/multiversx/mx-chain-core-go/core"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/operations"
+	"v1/queries"
+)
+
+// contractKindOf tells the system delegation contracts, deployed by the delegation manager in the metachain, from the
+// legacy WASM delegation contracts
+func contractKindOf(contract string) (operations.ContractKind, error) {
+	address, err := data.NewAddressFromBech32String(contract)
+	if err != nil {
+		return "", fmt.Errorf("%w for contract %s", err, contract)
+	}
+
+	addressBytes := address.AddressBytes()
+	if !chainCore.IsSmartContractAddress(addressBytes) {
+		return "", fmt.Errorf("%s is not a smart contract address", contract)
+	}
+	if queries.IsSystemDelegationContract(addressBytes) {
+		return operations.SystemDelegation, nil
+	}
+
+	return operations.LegacyDelegation, nil
+}
+
+// getNodeStates returns the state of every key added in the delegation contract, keyed by the hex encoded BLS key
+func getNodeStates(proxy interactors.Proxy, contract string, caller string) (map[string]string, error) {
+	return queries.GetAllNodeStates(proxy.(queries.VMQueryProxy), contract, caller)
+}
+
+// getRemainingUnBondPeriod returns the number of rounds left until the unstaked key can be unbonded
+func getRemainingUnBondPeriod(proxy interactors.Proxy, caller string, blsKey string) (uint64, error) {
+	decodedKey, err := hex.DecodeString(blsKey)
+	if err != nil {
+		return 0, fmt.Errorf("%w for key %s", err, blsKey)
+	}
+
+	return queries.GetRemainingUnBondPeriod(proxy.(queries.VMQueryProxy), caller, decodedKey)
+}
+
+// getStakingKeyStatus returns the status of the key in the staking SC: staked, jailed, queued, unStaked or
+// notRegistered for the keys the staking SC does not know about
+func getStakingKeyStatus(proxy interactors.Proxy, caller string, blsKey string) (string, error) {
+	decodedKey, err := hex.DecodeString(blsKey)
+	if err != nil {
+		return "", fmt.Errorf("%w for key %s", err, blsKey)
+	}
+
+	return queries.GetBLSKeyStatus(proxy.(queries.VMQueryProxy), caller, decodedKey)
+}
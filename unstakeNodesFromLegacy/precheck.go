@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/operations"
+)
+
+// exitCodeNoEligibleKeys is returned when none of the requested keys can go through the operation. It differs from
+// the exit code of a panic so scripts can tell it apart from a failure.
+const exitCodeNoEligibleKeys = 3
+
+const stateNotInContract = "notInContract"
+
+// keyCheck is the pre-check result for a requested key
+type keyCheck struct {
+	key           string
+	contractState string
+	stakingStatus string
+	skipReason    string
+}
+
+func (check *keyCheck) group() string {
+	return check.contractState + " / " + check.stakingStatus
+}
+
+// checkKeysForOperation returns the keys whose contract node state and staking SC status match the operation, the
+// other ones are logged and dropped. Keys waiting for unbond are also dropped until their unbond period passes. A
+// before/after table grouped by state is printed.
+func checkKeysForOperation(
+	proxy interactors.Proxy,
+	contract string,
+	ownerAddress core.AddressHandler,
+	operation *operations.NodeOperation,
+	blsKeys []string,
+) ([]string, error) {
+	caller, err := ownerAddress.AddressAsBech32String()
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := getNodeStates(proxy, contract, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]*keyCheck, 0, len(blsKeys))
+	eligible := make([]string, 0, len(blsKeys))
+	for _, blsKey := range blsKeys {
+		check, errCheck := checkKey(proxy, caller, states, operation, strings.ToLower(strings.TrimSpace(blsKey)))
+		if errCheck != nil {
+			return nil, errCheck
+		}
+
+		checks = append(checks, check)
+		if len(check.skipReason) > 0 {
+			log.Warn("key dropped", "key", check.key, "contract state", check.contractState,
+				"staking status", check.stakingStatus, "reason", check.skipReason)
+			continue
+		}
+
+		eligible = append(eligible, check.key)
+	}
+
+	printChecksTable(operation.Name, checks)
+	log.Info("keys checked", "num requested", len(blsKeys), "num eligible", len(eligible))
+
+	return eligible, nil
+}
+
+func checkKey(proxy interactors.Proxy, caller string, states map[string]string, operation *operations.NodeOperation, blsKey string) (*keyCheck, error) {
+	check := &keyCheck{
+		key:           blsKey,
+		contractState: stateNotInContract,
+	}
+
+	stakingStatus, err := getStakingKeyStatus(proxy, caller, blsKey)
+	if err != nil {
+		return nil, err
+	}
+	check.stakingStatus = stakingStatus
+
+	state, found := states[blsKey]
+	if !found {
+		check.skipReason = "key not added in the contract"
+		return check, nil
+	}
+	check.contractState = state
+
+	switch {
+	case state != operation.RequiredState:
+		check.skipReason = fmt.Sprintf("contract state should be %s", operation.RequiredState)
+	case !containsString(operation.StakingStatuses, stakingStatus):
+		check.skipReason = fmt.Sprintf("staking status should be %s", strings.Join(operation.StakingStatuses, " or "))
+	case operation.Name == operations.UnBondNodes:
+		remaining, errPeriod := getRemainingUnBondPeriod(proxy, caller, blsKey)
+		if errPeriod != nil {
+			return nil, errPeriod
+		}
+		if remaining > 0 {
+			check.skipReason = fmt.Sprintf("still in the unbond period for %d rounds", remaining)
+		}
+	}
+
+	return check, nil
+}
+
+// printChecksTable prints, for every contract state & staking status pair, how many keys were requested and how
+// many are kept for the operation
+func printChecksTable(operationName string, checks []*keyCheck) {
+	before := make(map[string]int)
+	after := make(map[string]int)
+	for _, check := range checks {
+		before[check.group()]++
+		if len(check.skipReason) == 0 {
+			after[check.group()]++
+		}
+	}
+
+	groups := make([]string, 0, len(before))
+	for group := range before {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	fmt.Printf("\n%s pre-check\n", operationName)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "CONTRACT STATE / STAKING STATUS\tBEFORE\tAFTER")
+	for _, group := range groups {
+		_, _ = fmt.Fprintf(writer, "%s\t%d\t%d\n", group, before[group], after[group])
+	}
+	_, _ = fmt.Fprintf(writer, "total\t%d\t%d\n", len(checks), sumCounts(after))
+	_ = writer.Flush()
+	fmt.Println()
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	return total
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	chainCore "github.com/multiversx/mx-chain-core-go/core"
+	"github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"v1/common"
+	"v1/operations"
+	"v1/queries"
+)
+
+const defaultScheduleFilename = "./unbondSchedule.json"
+
+const (
+	scheduleStatusUnStakeSent   = "unStakeSent"
+	scheduleStatusUnStakeFailed = "unStakeFailed"
+	scheduleStatusWaitingUnBond = "waitingUnBond"
+	scheduleStatusUnBondSent    = "unBondSent"
+	scheduleStatusUnBonded      = "unBonded"
+)
+
+type networkStatusProxy interface {
+	GetNetworkStatus(ctx context.Context, shardID uint32) (*data.NetworkStatus, error)
+}
+
+// scheduledKey tracks an unstaked key until it is unbonded. UnBondEpoch is the epoch the key can be unbonded from. The
+// transaction hashes are the ones of the transactions that carried the key.
+type scheduledKey struct {
+	Key           string `json:"key"`
+	Contract      string `json:"contract"`
+	Status        string `json:"status"`
+	UnStakeEpoch  uint64 `json:"unStakeEpoch"`
+	UnStakeTxHash string `json:"unStakeTxHash,omitempty"`
+	UnStakeSentAt int64  `json:"unStakeSentAt,omitempty"`
+	UnBondEpoch   uint64 `json:"unBondEpoch,omitempty"`
+	UnBondTxHash  string `json:"unBondTxHash,omitempty"`
+	UnBondSentAt  int64  `json:"unBondSentAt,omitempty"`
+}
+
+// unBondSchedule is the locally persisted list of unstaked keys waiting for unbond. A key whose unStakeNodes failed is
+// kept as unStakeFailed and no longer checked, until it is unstaked again.
+type unBondSchedule struct {
+	Keys []*scheduledKey `json:"keys"`
+}
+
+func loadSchedule(filename string) (*unBondSchedule, error) {
+	buff, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &unBondSchedule{Keys: make([]*scheduledKey, 0)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &unBondSchedule{}
+	err = json.Unmarshal(buff, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (schedule *unBondSchedule) find(contract string, blsKey string) *scheduledKey {
+	for _, entry := range schedule.Keys {
+		if entry.Contract == contract && entry.Key == blsKey {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// getCurrentEpoch returns the metachain epoch
+func getCurrentEpoch(proxy interactors.Proxy) (uint64, error) {
+	status, err := proxy.(networkStatusProxy).GetNetworkStatus(context.Background(), chainCore.MetachainShardId)
+	if err != nil {
+		return 0, err
+	}
+
+	return status.EpochNumber, nil
+}
+
+// recordUnStakedKeys adds the keys an unStakeNodes was sent for to the schedule, so the unbond daemon can pick them up.
+// keyHashes holds the hash of the transaction that carried each key.
+func recordUnStakedKeys(proxy interactors.Proxy, scheduleFile string, contract string, blsKeys []string, keyHashes map[string]string) {
+	epoch, err := getCurrentEpoch(proxy)
+	if err != nil {
+		log.Error("can not read the current epoch, the keys are not scheduled for unbond", "error", err)
+		return
+	}
+
+	schedule, err := loadSchedule(scheduleFile)
+	if err != nil {
+		log.Error("can not load the unbond schedule, the keys are not scheduled for unbond", "file", scheduleFile, "error", err)
+		return
+	}
+
+	for _, blsKey := range blsKeys {
+		entry := schedule.find(contract, blsKey)
+		if entry == nil {
+			entry = &scheduledKey{
+				Key:      blsKey,
+				Contract: contract,
+			}
+			schedule.Keys = append(schedule.Keys, entry)
+		}
+
+		entry.Status = scheduleStatusUnStakeSent
+		entry.UnStakeEpoch = epoch
+		entry.UnStakeTxHash = keyHashes[blsKey]
+		entry.UnStakeSentAt = time.Now().Unix()
+		entry.UnBondEpoch = 0
+		entry.UnBondTxHash = ""
+	}
+
+	err = common.SaveJSONFile(scheduleFile, schedule)
+	if err != nil {
+		log.Error("can not save the unbond schedule", "file", scheduleFile, "error", err)
+		return
+	}
+
+	log.Info("keys scheduled for unbond", "file", scheduleFile, "num keys", len(blsKeys), "unstake epoch", epoch)
+}
+
+// runUnBondDaemon periodically checks the scheduled keys of the contract and sends unBondNodes for the ones whose
+// unbond period passed. The schedule is saved after every check so the daemon can be restarted at any time.
+func runUnBondDaemon(
+	proxy interactors.Proxy,
+	contract string,
+	ownerAddress core.AddressHandler,
+	kind operations.ContractKind,
+	skBytes []byte,
+	scheduleFile string,
+	pollInterval time.Duration,
+	maxKeysPerTx int,
+) {
+	log.Info("unbond daemon started", "contract", contract, "schedule", scheduleFile, "poll interval", pollInterval)
+
+	for {
+		err := checkSchedule(proxy, contract, ownerAddress, kind, skBytes, scheduleFile, maxKeysPerTx)
+		if err != nil {
+			log.Error("unbond schedule check failed, retrying on the next poll", "error", err)
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+func checkSchedule(
+	proxy interactors.Proxy,
+	contract string,
+	ownerAddress core.AddressHandler,
+	kind operations.ContractKind,
+	skBytes []byte,
+	scheduleFile string,
+	maxKeysPerTx int,
+) error {
+	schedule, err := loadSchedule(scheduleFile)
+	if err != nil {
+		return err
+	}
+	epoch, err := getCurrentEpoch(proxy)
+	if err != nil {
+		return err
+	}
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	if err != nil {
+		return err
+	}
+	caller, err := ownerAddress.AddressAsBech32String()
+	if err != nil {
+		return err
+	}
+
+	dueKeys := make([]string, 0)
+	for _, entry := range schedule.Keys {
+		if entry.Contract != contract || entry.Status == scheduleStatusUnBonded || entry.Status == scheduleStatusUnStakeFailed {
+			continue
+		}
+
+		err = updateScheduledKey(proxy, caller, entry, epoch, netConfigs)
+		if err != nil {
+			return err
+		}
+		if entry.Status == scheduleStatusWaitingUnBond && epoch >= entry.UnBondEpoch {
+			dueKeys = append(dueKeys, entry.Key)
+		}
+	}
+
+	if len(dueKeys) > 0 {
+		err = sendScheduledUnBond(proxy, contract, ownerAddress, kind, skBytes, schedule, dueKeys, maxKeysPerTx)
+		if err != nil {
+			return err
+		}
+	}
+
+	logScheduleSummary(schedule, contract, epoch)
+
+	return common.SaveJSONFile(scheduleFile, schedule)
+}
+
+// updateScheduledKey moves the key to its next status based on its staking SC status. The unbond epoch is computed
+// once the unstake is executed, from the remaining unbond rounds and the rounds per epoch of the network. The network
+// config the proxy exposes holds no unbond period and the staking SC counts the unbond period of a key in rounds, from
+// its unstake nonce, so its remaining rounds are the only exact source. The due keys are checked again for remaining
+// rounds before unBondNodes is sent.
+func updateScheduledKey(proxy interactors.Proxy, caller string, entry *scheduledKey, epoch uint64, netConfigs *data.NetworkConfig) error {
+	status, err := getStakingKeyStatus(proxy, caller, entry.Key)
+	if err != nil {
+		return err
+	}
+
+	switch entry.Status {
+	case scheduleStatusUnStakeSent:
+		if status != queries.StakingStatusUnStaked {
+			if hasTransactionFailed(proxy, entry.UnStakeTxHash, entry.UnStakeSentAt) {
+				log.Warn("unStakeNodes failed, the key is no longer scheduled for unbond", "key", entry.Key, "tx hash", entry.UnStakeTxHash)
+				entry.Status = scheduleStatusUnStakeFailed
+			}
+			return nil
+		}
+
+		remainingRounds, errPeriod := getRemainingUnBondPeriod(proxy, caller, entry.Key)
+		if errPeriod != nil {
+			return errPeriod
+		}
+		roundsPerEpoch := uint64(netConfigs.RoundsPerEpoch)
+		if roundsPerEpoch == 0 {
+			roundsPerEpoch = 1
+		}
+		entry.UnBondEpoch = epoch + (remainingRounds+roundsPerEpoch-1)/roundsPerEpoch
+		entry.Status = scheduleStatusWaitingUnBond
+		log.Info("unstake executed", "key", entry.Key, "unbond epoch", entry.UnBondEpoch)
+	case scheduleStatusWaitingUnBond:
+		if status == queries.StakingStatusNotRegistered {
+			entry.Status = scheduleStatusUnBonded
+		}
+	case scheduleStatusUnBondSent:
+		if status == queries.StakingStatusNotRegistered {
+			entry.Status = scheduleStatusUnBonded
+			log.Info("key unbonded", "key", entry.Key)
+		}
+		if status == queries.StakingStatusUnStaked && hasTransactionFailed(proxy, entry.UnBondTxHash, entry.UnBondSentAt) {
+			// the unbond transaction failed, the key is retried on this check
+			log.Warn("key still unstaked after unBondNodes, retrying", "key", entry.Key, "tx hash", entry.UnBondTxHash)
+			entry.Status = scheduleStatusWaitingUnBond
+		}
+	}
+
+	return nil
+}
+
+// hasTransactionFailed returns true if the transaction that carried the key did not succeed
+func hasTransactionFailed(proxy interactors.Proxy, txHash string, sentAt int64) bool {
+	if len(txHash) == 0 {
+		return false
+	}
+
+	failedHash, err := queries.FindFailedTransaction(proxy.(queries.TransactionStatusProxy), []string{txHash}, time.Unix(sentAt, 0))
+	if err != nil {
+		log.Debug("can not read the transaction status", "tx hash", txHash, "error", err)
+	}
+
+	return len(failedHash) > 0
+}
+
+// sendScheduledUnBond runs the usual pre-check on the due keys, which also confirms that no unbond rounds are left,
+// then sends unBondNodes for the eligible ones
+func sendScheduledUnBond(
+	proxy interactors.Proxy,
+	contract string,
+	ownerAddress core.AddressHandler,
+	kind operations.ContractKind,
+	skBytes []byte,
+	schedule *unBondSchedule,
+	dueKeys []string,
+	maxKeysPerTx int,
+) error {
+	operation := operations.Supported[operations.UnBondNodes]
+	eligibleKeys, err := checkKeysForOperation(proxy, contract, ownerAddress, operation, dueKeys)
+	if err != nil {
+		return err
+	}
+	if len(eligibleKeys) == 0 {
+		log.Info("the due keys are not eligible for unbond yet", "num keys", len(dueKeys))
+		return nil
+	}
+
+	keyHashes, err := sendNodesTransactions(proxy, contract, skBytes, operation.Name, operation.GasModels[kind], eligibleKeys, maxKeysPerTx)
+	if err != nil {
+		return err
+	}
+	log.Info("unBondNodes sent", "num keys", len(eligibleKeys), "hashes", uniqueHashes(keyHashes))
+
+	for _, blsKey := range eligibleKeys {
+		entry := schedule.find(contract, blsKey)
+		entry.Status = scheduleStatusUnBondSent
+		entry.UnBondTxHash = keyHashes[blsKey]
+		entry.UnBondSentAt = time.Now().Unix()
+	}
+
+	return nil
+}
+
+func logScheduleSummary(schedule *unBondSchedule, contract string, epoch uint64) {
+	counts := make(map[string]int)
+	for _, entry := range schedule.Keys {
+		if entry.Contract == contract {
+			counts[entry.Status]++
+		}
+	}
+
+	log.Info("unbond schedule",
+		"epoch", epoch,
+		scheduleStatusUnStakeSent, counts[scheduleStatusUnStakeSent],
+		scheduleStatusUnStakeFailed, counts[scheduleStatusUnStakeFailed],
+		scheduleStatusWaitingUnBond, counts[scheduleStatusWaitingUnBond],
+		scheduleStatusUnBondSent, counts[scheduleStatusUnBondSent],
+		scheduleStatusUnBonded, counts[scheduleStatusUnBonded])
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/multiversx/mx-chain-crypto-go/signing"
+	"github.com/multiversx/mx-chain-crypto-go/signing/ed25519"
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-sdk-go/examples"
+	"v1/common"
+	"v1/keys"
+)
+
+const stateFilename = "./legacyWithdrawals.json"
+
+var (
+	suite  = ed25519.NewEd25519()
+	keyGen = signing.NewKeyGenerator(suite)
+	log    = logger.GetOrCreate("withdrawFromLegacy")
+)
+
+// withdrawFromLegacy takes the stake of many delegator wallets out of a legacy delegation contract: it sends unStake
+// for the active stake, unBond once the stake can be unbonded and follows each wallet until the funds are back
+func main() {
+	contract := flag.String("contract", "", "the legacy delegation contract, required")
+	walletsDir := flag.String("wallets-dir", "", "the directory holding the delegator wallets, PEM or JSON keystore files")
+	wallets := flag.String("wallets", "", "comma separated delegator wallet files")
+	passwordFile := flag.String("password-file", "", "the file holding the password shared by the key files, "+
+		"defaults to the "+keys.PasswordEnvVariable+" variable or an interactive prompt")
+	passwordFiles := keys.PasswordFiles{}
+	flag.Var(passwordFiles, "file-password", keys.PasswordFilesUsage)
+	stateFile := flag.String("state-file", stateFilename, "the file the withdrawal progress of every wallet is saved in")
+	pollInterval := flag.Duration("poll-interval", 10*time.Minute, "how often the wallets are checked")
+	once := flag.Bool("once", false, "check the wallets once instead of polling until all the funds are withdrawn")
+	flag.Parse()
+
+	if len(*contract) == 0 {
+		panic("no contract provided, use -contract")
+	}
+
+	walletFiles, err := listWalletFiles(*walletsDir, *wallets)
+	if err != nil {
+		panic(err)
+	}
+	if len(walletFiles) == 0 {
+		panic("no delegator wallets provided, use -wallets-dir or -wallets")
+	}
+
+	delegators, err := loadDelegators(walletFiles, keys.NewPasswordProvider(*passwordFile, passwordFiles))
+	if err != nil {
+		panic(err)
+	}
+	defer wipeDelegators(delegators)
+
+	proxy, err := common.NewProxy(examples.TestnetGateway)
+	if err != nil {
+		panic(err)
+	}
+	for {
+		done, errCheck := checkWithdrawals(proxy, *contract, delegators, *stateFile)
+		if errCheck != nil {
+			log.Error("withdrawal check failed, retrying on the next poll", "error", errCheck)
+		}
+		if done {
+			log.Info("all the delegator funds are withdrawn", "num wallets", len(delegators))
+			return
+		}
+		if *once {
+			return
+		}
+
+		time.Sleep(*pollInterval)
+	}
+}
+
+// listWalletFiles returns the .pem & .json files of the directory followed by the explicitly provided files
+func listWalletFiles(dir string, list string) ([]string, error) {
+	files := make([]string, 0)
+	if len(dir) > 0 {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			extension := filepath.Ext(entry.Name())
+			if entry.IsDir() || (extension != ".pem" && extension != ".json") {
+				continue
+			}
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	for _, file := range strings.Split(list, ",") {
+		file = strings.TrimSpace(file)
+		if len(file) > 0 {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
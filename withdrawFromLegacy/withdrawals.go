@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-sdk-go/blockchain/cryptoProvider"
+	"github.com/multiversx/mx-sdk-go/builders"
+	"github.com/multiversx/mx-sdk-go/core"
+	"github.com/multiversx/mx-sdk-go/data"
+	"github.com/multiversx/mx-sdk-go/interactors"
+	"github.com/multiversx/mx-sdk-go/workflows"
+	"v1/common"
+	"v1/keys"
+	"v1/queries"
+)
+
+// the legacy contract unStake & unBond calls only move funds between the delegator buckets, the unBond one also
+// transfers the funds back to the delegator
+const unStakeGas = 100000000
+const unBondGas = 100000000
+
+const (
+	withdrawalStatusPending        = "pending"
+	withdrawalStatusUnStakeSent    = "unStakeSent"
+	withdrawalStatusWaitingUnBond  = "waitingUnBond"
+	withdrawalStatusUnBondSent     = "unBondSent"
+	withdrawalStatusWithdrawn      = "withdrawn"
+	withdrawalStatusNothingToClaim = "nothingToClaim"
+)
+
+type txHashComputer interface {
+	ComputeTxHash(tx *transaction.FrontendTransaction) ([]byte, error)
+}
+
+// delegator is a wallet holding stake in the legacy contract
+type delegator struct {
+	skBytes       []byte
+	address       core.AddressHandler
+	bech32Address string
+}
+
+// withdrawal is the progress of a delegator wallet. The values are in the smallest denomination.
+type withdrawal struct {
+	Address             string `json:"address"`
+	Status              string `json:"status"`
+	UnStakedValue       string `json:"unStakedValue,omitempty"`
+	UnStakeTxHash       string `json:"unStakeTxHash,omitempty"`
+	UnStakeSentAt       int64  `json:"unStakeSentAt,omitempty"`
+	UnBondValue         string `json:"unBondValue,omitempty"`
+	UnBondTxHash        string `json:"unBondTxHash,omitempty"`
+	UnBondSentAt        int64  `json:"unBondSentAt,omitempty"`
+	BalanceBeforeUnBond string `json:"balanceBeforeUnBond,omitempty"`
+	WithdrawnValue      string `json:"withdrawnValue,omitempty"`
+}
+
+type withdrawalsState struct {
+	Contract    string                 `json:"contract"`
+	Withdrawals map[string]*withdrawal `json:"withdrawals"`
+}
+
+// delegatorFunds is what a delegator holds in the contract and in its wallet
+type delegatorFunds struct {
+	*queries.LegacyStake
+	balance *big.Int
+}
+
+func loadDelegators(walletFiles []string, passwords *keys.PasswordProvider) ([]*delegator, error) {
+	wallet := interactors.NewWallet()
+	delegators := make([]*delegator, 0, len(walletFiles))
+	for _, file := range walletFiles {
+		skBytes, err := keys.LoadWalletKey(file, passwords)
+		if err != nil {
+			wipeDelegators(delegators)
+			return nil, fmt.Errorf("%w while loading %s", err, file)
+		}
+
+		address, err := wallet.GetAddressFromPrivateKey(skBytes)
+		if err != nil {
+			keys.Wipe(skBytes)
+			wipeDelegators(delegators)
+			return nil, fmt.Errorf("%w for %s", err, file)
+		}
+		bech32Address, _ := address.AddressAsBech32String()
+
+		delegators = append(delegators, &delegator{
+			skBytes:       skBytes,
+			address:       address,
+			bech32Address: bech32Address,
+		})
+	}
+
+	return delegators, nil
+}
+
+func wipeDelegators(delegators []*delegator) {
+	for _, d := range delegators {
+		keys.Wipe(d.skBytes)
+	}
+}
+
+func loadWithdrawalsState(filename string, contract string) (*withdrawalsState, error) {
+	state := &withdrawalsState{
+		Contract:    contract,
+		Withdrawals: make(map[string]*withdrawal),
+	}
+
+	buff, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(buff, state)
+	if err != nil {
+		return nil, err
+	}
+	if state.Contract != contract {
+		return nil, fmt.Errorf("%s tracks withdrawals from %s, not from %s", filename, state.Contract, contract)
+	}
+
+	return state, nil
+}
+
+// checkWithdrawals moves every delegator one step further and saves the progress. It returns true once all the
+// delegators either withdrew their funds or had nothing to withdraw.
+func checkWithdrawals(proxy interactors.Proxy, contract string, delegators []*delegator, stateFile string) (bool, error) {
+	state, err := loadWithdrawalsState(stateFile, contract)
+	if err != nil {
+		return false, err
+	}
+	netConfigs, err := proxy.GetNetworkConfig(context.Background())
+	if err != nil {
+		return false, err
+	}
+	txBuilder, err := builders.NewTxBuilder(cryptoProvider.NewSigner())
+	if err != nil {
+		return false, err
+	}
+	ti, err := interactors.NewTransactionInteractor(proxy, txBuilder)
+	if err != nil {
+		return false, err
+	}
+
+	done := true
+	for _, d := range delegators {
+		entry, found := state.Withdrawals[d.bech32Address]
+		if !found {
+			entry = &withdrawal{
+				Address: d.bech32Address,
+				Status:  withdrawalStatusPending,
+			}
+			state.Withdrawals[d.bech32Address] = entry
+		}
+
+		errStep := processWithdrawal(proxy, contract, d, entry, netConfigs, ti, txBuilder)
+		if errStep != nil {
+			log.Error("withdrawal step failed", "delegator", d.bech32Address, "status", entry.Status, "error", errStep)
+		}
+		if entry.Status != withdrawalStatusWithdrawn && entry.Status != withdrawalStatusNothingToClaim {
+			done = false
+		}
+	}
+
+	hashes, err := ti.SendTransactionsAsBunch(context.Background(), 100)
+	if err != nil {
+		return false, err
+	}
+	if len(hashes) > 0 {
+		log.Info("transactions sent", "hashes", hashes)
+	}
+
+	logWithdrawalsSummary(state)
+
+	return done, common.SaveJSONFile(stateFile, state)
+}
+
+// processWithdrawal checks the delegator funds and, if needed, queues the transaction of the next step
+func processWithdrawal(
+	proxy interactors.Proxy,
+	contract string,
+	d *delegator,
+	entry *withdrawal,
+	netConfigs *data.NetworkConfig,
+	ti workflows.TransactionInteractor,
+	hasher txHashComputer,
+) error {
+	funds, err := getDelegatorFunds(proxy, contract, d)
+	if err != nil {
+		return err
+	}
+
+	switch entry.Status {
+	case withdrawalStatusPending:
+		if funds.IsEmpty() {
+			entry.Status = withdrawalStatusNothingToClaim
+			return nil
+		}
+		unStakeable := funds.UnStakeable()
+		if unStakeable.Sign() == 0 {
+			entry.Status = withdrawalStatusWaitingUnBond
+			return nil
+		}
+
+		txData := "unStake@" + common.HexBigInt(unStakeable)
+		entry.UnStakeTxHash, err = addDelegatorTx(proxy, contract, d, txData, unStakeGas, netConfigs, ti, hasher)
+		if err != nil {
+			return err
+		}
+		entry.UnStakedValue = unStakeable.String()
+		entry.UnStakeSentAt = time.Now().Unix()
+		entry.Status = withdrawalStatusUnStakeSent
+	case withdrawalStatusUnStakeSent:
+		if funds.UnStakeable().Sign() == 0 {
+			entry.Status = withdrawalStatusWaitingUnBond
+			return nil
+		}
+
+		if hasTransactionFailed(proxy, entry.UnStakeTxHash, entry.UnStakeSentAt) {
+			// the unStake transaction failed, it is sent again on the next check
+			log.Warn("stake left after unStake, retrying", "delegator", d.bech32Address, "tx hash", entry.UnStakeTxHash)
+			entry.Status = withdrawalStatusPending
+		}
+	case withdrawalStatusWaitingUnBond:
+		if funds.UnBondable.Sign() == 0 {
+			return nil
+		}
+
+		entry.UnBondTxHash, err = addDelegatorTx(proxy, contract, d, "unBond", unBondGas, netConfigs, ti, hasher)
+		if err != nil {
+			return err
+		}
+		entry.UnBondValue = funds.UnBondable.String()
+		entry.BalanceBeforeUnBond = funds.balance.String()
+		entry.UnBondSentAt = time.Now().Unix()
+		entry.Status = withdrawalStatusUnBondSent
+	case withdrawalStatusUnBondSent:
+		balanceBefore, _ := big.NewInt(0).SetString(entry.BalanceBeforeUnBond, 10)
+		if balanceBefore == nil || funds.balance.Cmp(balanceBefore) <= 0 {
+			if hasTransactionFailed(proxy, entry.UnBondTxHash, entry.UnBondSentAt) {
+				// the unbond transaction failed, it is sent again on the next check
+				log.Warn("funds not withdrawn after unBond, retrying", "delegator", d.bech32Address, "tx hash", entry.UnBondTxHash)
+				entry.Status = withdrawalStatusWaitingUnBond
+			}
+			return nil
+		}
+
+		// the balance difference is net of the unBond transaction fee
+		withdrawn := big.NewInt(0).Sub(funds.balance, balanceBefore)
+		previouslyWithdrawn, ok := big.NewInt(0).SetString(entry.WithdrawnValue, 10)
+		if ok {
+			withdrawn.Add(withdrawn, previouslyWithdrawn)
+		}
+		entry.WithdrawnValue = withdrawn.String()
+
+		if funds.HasLockedFunds() {
+			// part of the unstaked funds were still in their unbond period
+			entry.Status = withdrawalStatusWaitingUnBond
+			log.Info("funds partially withdrawn", "delegator", d.bech32Address, "value", entry.WithdrawnValue)
+			return nil
+		}
+
+		entry.Status = withdrawalStatusWithdrawn
+		log.Info("funds withdrawn", "delegator", d.bech32Address, "value", entry.WithdrawnValue)
+	}
+
+	return nil
+}
+
+// hasTransactionFailed returns true if the transaction did not succeed, a dropped one included
+func hasTransactionFailed(proxy interactors.Proxy, txHash string, sentAt int64) bool {
+	failedHash, err := queries.FindFailedTransaction(proxy.(queries.TransactionStatusProxy), []string{txHash}, time.Unix(sentAt, 0))
+	if err != nil {
+		log.Debug("can not read the transaction status", "tx hash", txHash, "error", err)
+	}
+
+	return len(failedHash) > 0
+}
+
+func getDelegatorFunds(proxy interactors.Proxy, contract string, d *delegator) (*delegatorFunds, error) {
+	stake, err := queries.GetLegacyStake(proxy.(queries.VMQueryProxy), contract, d.bech32Address, d.address.AddressBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := proxy.GetAccount(context.Background(), d.address)
+	if err != nil {
+		return nil, err
+	}
+	balance, ok := big.NewInt(0).SetString(account.Balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %s for %s", account.Balance, d.bech32Address)
+	}
+
+	return &delegatorFunds{
+		LegacyStake: stake,
+		balance:     balance,
+	}, nil
+}
+
+// addDelegatorTx signs the call and queues it in the interactor. The transaction hash is computed locally so it can
+// be saved before the bunch is sent.
+func addDelegatorTx(
+	proxy interactors.Proxy,
+	contract string,
+	d *delegator,
+	txData string,
+	gasLimit uint64,
+	netConfigs *data.NetworkConfig,
+	ti workflows.TransactionInteractor,
+	hasher txHashComputer,
+) (string, error) {
+	holder, err := cryptoProvider.NewCryptoComponentsHolder(keyGen, d.skBytes)
+	if err != nil {
+		return "", err
+	}
+
+	tx, _, err := proxy.(workflows.ProxyHandler).GetDefaultTransactionArguments(context.Background(), d.address, netConfigs)
+	if err != nil {
+		return "", err
+	}
+
+	tx.Receiver = contract
+	tx.Value = "0"
+	tx.Data = []byte(txData)
+	tx.GasLimit = gasLimit + netConfigs.GasPerDataByte*uint64(len(tx.Data))
+
+	err = ti.ApplyUserSignature(holder, &tx)
+	if err != nil {
+		return "", err
+	}
+	ti.AddTransaction(&tx)
+
+	hash, err := hasher.ComputeTxHash(&tx)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("generated tx", "nonce", tx.Nonce, "sender", tx.Sender, "receiver", tx.Receiver, "gasLimit", tx.GasLimit, "data", txData)
+
+	return hex.EncodeToString(hash), nil
+}
+
+func logWithdrawalsSummary(state *withdrawalsState) {
+	counts := make(map[string]int)
+	withdrawn := big.NewInt(0)
+	for _, entry := range state.Withdrawals {
+		counts[entry.Status]++
+		value, ok := big.NewInt(0).SetString(entry.WithdrawnValue, 10)
+		if ok {
+			withdrawn.Add(withdrawn, value)
+		}
+	}
+
+	log.Info("withdrawals",
+		withdrawalStatusPending, counts[withdrawalStatusPending],
+		withdrawalStatusUnStakeSent, counts[withdrawalStatusUnStakeSent],
+		withdrawalStatusWaitingUnBond, counts[withdrawalStatusWaitingUnBond],
+		withdrawalStatusUnBondSent, counts[withdrawalStatusUnBondSent],
+		withdrawalStatusWithdrawn, counts[withdrawalStatusWithdrawn],
+		withdrawalStatusNothingToClaim, counts[withdrawalStatusNothingToClaim],
+		"total withdrawn", withdrawn.String())
+}